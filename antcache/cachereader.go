@@ -14,11 +14,18 @@ import (
 
 // Cachereader is a special reader that caches
 // a response when it is closed.
+//
+// The response is cached only if its body was read until
+// `io.EOF` and it does not exceed max bytes, a body that
+// is closed early, e.g. because it was truncated, is not cached.
 type cachereader struct {
 	resp  *http.Response
 	key   uint64
 	rc    io.ReadCloser
 	buf   bytes.Buffer
+	max   int64
+	eof   bool
+	over  bool
 	once  sync.Once
 	ctx   context.Context
 	store func(ctx context.Context, key uint64, v []byte) error
@@ -26,9 +33,21 @@ type cachereader struct {
 
 // Read implementation.
 func (cr *cachereader) Read(p []byte) (n int, err error) {
-	if n, err = cr.rc.Read(p); n > 0 {
-		cr.buf.Write(p[:n])
+	n, err = cr.rc.Read(p)
+
+	if n > 0 && !cr.over {
+		if cr.max > 0 && int64(cr.buf.Len()+n) > cr.max {
+			cr.over = true
+			cr.buf = bytes.Buffer{}
+		} else {
+			cr.buf.Write(p[:n])
+		}
+	}
+
+	if err == io.EOF {
+		cr.eof = true
 	}
+
 	return n, err
 }
 
@@ -37,6 +56,10 @@ func (cr *cachereader) Close() error {
 	cerr := cr.rc.Close()
 
 	cr.once.Do(func() {
+		if !cr.eof || cr.over {
+			return
+		}
+
 		resp := *cr.resp
 
 		r := bytes.NewReader(cr.buf.Bytes())
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -109,6 +109,8 @@ type DebugFunc func(format string, args ...any)
 //	Open("root", Debug(log.Printf))
 //
 // Debug logs are automatically prefixed with `"antcache/disk: "`.
+//
+// Deprecated: use Logger.
 func Debug(f DebugFunc) DiskOption {
 	return func(ds *Diskstore) error {
 		ds.debug = f
@@ -116,6 +118,22 @@ func Debug(f DebugFunc) DiskOption {
 	}
 }
 
+// Logger sets the logger to l.
+//
+// The diskstore logs its activity at debug level
+// and errors that occur in the background at error level.
+//
+// By default the diskstore does not log.
+func Logger(l *slog.Logger) DiskOption {
+	return func(ds *Diskstore) error {
+		if l == nil {
+			return errors.New("antcache: logger must be non-nil")
+		}
+		ds.logger = l
+		return nil
+	}
+}
+
 // Diskstore implements disk cache storage.
 //
 // The storage is expected to be configured with
@@ -142,6 +160,7 @@ type Diskstore struct {
 	ready    map[uint64]file
 	now      func() time.Time
 	debug    DebugFunc
+	logger   *slog.Logger
 	compress bool
 }
 
@@ -163,6 +182,7 @@ func Open(path string, opts ...DiskOption) (*Diskstore, error) {
 		ticker:   time.NewTicker(5 * time.Minute),
 		now:      time.Now,
 		debug:    nil,
+		logger:   slog.New(slog.DiscardHandler),
 		compress: false,
 	}
 
@@ -217,6 +237,7 @@ func (d *Diskstore) init() error {
 	}
 
 	d.debugf("opened root %s", d.path)
+	d.logger.Debug("antcache/disk: opened root", "path", d.path)
 	d.dir = f
 	return nil
 }
@@ -268,13 +289,13 @@ func (d *Diskstore) warmup() {
 
 			n, err := strconv.ParseUint(name, 10, 64)
 			if err != nil {
-				log.Printf("antcache: disk cache invalid entry - %s", name)
+				d.logger.Error("antcache/disk: invalid entry", "path", path)
 				continue
 			}
 
 			stat, err := os.Stat(path)
 			if err != nil {
-				log.Printf("antcache: disk stat %s - %s", path, err)
+				d.logger.Error("antcache/disk: stat", "path", path, "error", err)
 				continue
 			}
 
@@ -296,6 +317,7 @@ func (d *Diskstore) warmup() {
 	d.readymu.Unlock()
 
 	d.debugf("found %d cached pages", len(files))
+	d.logger.Debug("antcache/disk: found cached pages", "count", len(files))
 }
 
 // Sweeper sweeps the directory.
@@ -320,7 +342,7 @@ func (d *Diskstore) sweeper() {
 
 		case <-d.ticker.C:
 			if _, err := d.sweep(); err != nil {
-				log.Printf("antcache: disk sweep - %s", err)
+				d.logger.Error("antcache/disk: sweep", "error", err)
 			}
 		}
 	}
@@ -361,7 +383,7 @@ func (d *Diskstore) sweep() (int, error) {
 	for _, f := range remove {
 		if _, ok := d.ready[f.key]; ok {
 			if err := os.Remove(f.path); err != nil {
-				log.Printf("antcache: disk remove - %s", err)
+				d.logger.Error("antcache/disk: remove", "path", f.path, "error", err)
 				continue
 			}
 			delete(d.ready, f.key)
@@ -371,6 +393,7 @@ func (d *Diskstore) sweep() (int, error) {
 
 	if removed > 0 {
 		d.debugf("removed %d expired pages", removed)
+		d.logger.Debug("antcache/disk: removed expired pages", "count", removed)
 	}
 
 	return removed, nil
@@ -419,11 +442,12 @@ func (d *Diskstore) Store(ctx context.Context, key uint64, v []byte) error {
 	}
 
 	d.debugf("store %d", key)
+	d.logger.DebugContext(ctx, "antcache/disk: store", "cache_key", key)
 	return nil
 }
 
 // Load implementation.
-func (d *Diskstore) Load(_ context.Context, key uint64) (v []byte, err error) {
+func (d *Diskstore) Load(ctx context.Context, key uint64) (v []byte, err error) {
 	d.readymu.RLock()
 	defer d.readymu.RUnlock()
 
@@ -431,7 +455,8 @@ func (d *Diskstore) Load(_ context.Context, key uint64) (v []byte, err error) {
 		if v, err = os.ReadFile(f.path); err != nil {
 			return nil, fmt.Errorf("antcache: disk read %q - %w", f.path, err)
 		}
-		d.debugf("load %d %s", key)
+		d.debugf("load %d", key)
+		d.logger.DebugContext(ctx, "antcache/disk: load", "cache_key", key)
 	}
 
 	if v != nil && d.compress {
@@ -492,5 +517,6 @@ func (d *Diskstore) Close() error {
 	}
 
 	d.debugf("closed %s", d.path)
+	d.logger.Debug("antcache/disk: closed", "path", d.path)
 	return nil
 }
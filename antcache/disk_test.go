@@ -2,7 +2,10 @@ package antcache
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -51,6 +54,38 @@ func TestDiskstore(t *testing.T) {
 		assert.NoError(d.Close())
 	})
 
+	t.Run("open with logger", func(t *testing.T) {
+		var assert = require.New(t)
+		var ctx = context.Background()
+		var buf strings.Builder
+		var logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+
+		d, err := Open(tempdir(t), Logger(logger))
+		assert.NoError(err)
+		assert.NoError(d.Store(ctx, 1, []byte("a")))
+		assert.NoError(d.Close())
+
+		assert.Contains(buf.String(), `msg="antcache/disk: opened root"`)
+		assert.Contains(buf.String(), `msg="antcache/disk: store" cache_key=1`)
+	})
+
+	t.Run("open with debug", func(t *testing.T) {
+		var assert = require.New(t)
+		var dir = tempdir(t)
+		var lines []string
+
+		d, err := Open(dir, Debug(func(format string, args ...any) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		}))
+		assert.NoError(err)
+		assert.NoError(d.Close())
+
+		assert.NotEmpty(lines)
+		assert.Equal("antcache/disk: opened root "+dir, lines[0])
+	})
+
 	t.Run("wait", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 )
 
@@ -62,7 +63,8 @@ type Storage interface {
 	// Store stores the given response.
 	//
 	// The method is called just after the response's body is
-	// closed, the value contains the full response including headers.
+	// read until EOF and closed, the value contains the full
+	// response including headers.
 	Store(ctx context.Context, key uint64, value []byte) error
 
 	// Load loads a response by its key.
@@ -94,11 +96,73 @@ func WithStorage(s Storage) Option {
 	}
 }
 
+// WithLogger sets the logger to l.
+//
+// The cache logs hits, misses and validations at debug level.
+//
+// By default the cache does not log.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Cache) error {
+		if l == nil {
+			return errors.New("antcache: logger must be non-nil")
+		}
+		c.logger = l
+		return nil
+	}
+}
+
+// WithMaxSize sets the maximum body size to store to n bytes.
+//
+// Responses with larger bodies are passed through without
+// being buffered or stored.
+//
+// By default the cache stores bodies up to 10MB.
+func WithMaxSize(n int64) Option {
+	return func(c *Cache) error {
+		if n <= 0 {
+			return errors.New("antcache: max size must be greater than 0")
+		}
+		c.maxSize = n
+		return nil
+	}
+}
+
 // Cache implements an HTTP cache.
 type Cache struct {
 	storage  Storage
 	strategy strategy
 	client   Client
+	logger   *slog.Logger
+	maxSize  int64
+}
+
+// Wrap returns a client that caches the responses of next.
+//
+// The client shares the cache, which allows the cache to
+// slot into a fetcher's middleware chain.
+//
+//	eng, err := ant.NewEngine(ant.EngineConfig{
+//	  Fetcher: &ant.Fetcher{
+//	    Middleware: []ant.Middleware{
+//	      func(next ant.Client) ant.Client {
+//	        return cache.Wrap(next)
+//	      },
+//	    },
+//	  },
+//	})
+func (c *Cache) Wrap(next Client) Client {
+	return &wrapper{cache: c, next: next}
+}
+
+// Wrapper is a client that caches the responses of next.
+type wrapper struct {
+	cache *Cache
+	next  Client
+}
+
+// Do implementation.
+func (w *wrapper) Do(req *http.Request) (*http.Response, error) {
+	return w.cache.do(w.next, req)
 }
 
 // New returns a new cache with the given options.
@@ -107,6 +171,8 @@ func New(c Client, opts ...Option) (*Cache, error) {
 		strategy: rfc7234{},
 		storage:  &memstore{},
 		client:   c,
+		logger:   slog.New(slog.DiscardHandler),
+		maxSize:  10 << 20,
 	}
 
 	if c == nil {
@@ -135,31 +201,43 @@ func New(c Client, opts ...Option) (*Cache, error) {
 // and if the response can be stored, it will store it when its body
 // is closed, if the body is not closed, the response is never stored.
 //
+// Cached responses have an `X-From-Cache: 1` header, responses of
+// cacheable requests that were not found have `X-From-Cache: 0`.
+//
 // If there was an error loading a cached response the method returns
 // the error and discards the response's body, if an error occurs
 // when storing the response body, the response's Close() method
 // will return the error.
 func (c *Cache) Do(req *http.Request) (*http.Response, error) {
+	return c.do(c.client, req)
+}
+
+// Do performs the given request with client.
+func (c *Cache) do(client Client, req *http.Request) (*http.Response, error) {
 	if !c.strategy.cache(req) {
-		return c.client.Do(req)
+		return client.Do(req)
 	}
 
 	var key = keyof(req)
 
-	resp, err := c.load(key, req)
+	resp, err := c.load(client, key, req)
 	if err != nil {
 		return nil, err
 	}
 	if resp != nil {
+		c.log(req, "antcache: hit", key, resp.StatusCode)
 		resp.Header.Set("X-From-Cache", "1")
 		return resp, nil
 	}
 
-	resp, err = c.client.Do(req)
+	resp, err = client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
+	c.log(req, "antcache: miss", key, resp.StatusCode)
+	resp.Header.Set("X-From-Cache", "0")
+
 	if c.strategy.store(resp) {
 		c.store(key, resp)
 	}
@@ -178,7 +256,7 @@ func (c *Cache) Do(req *http.Request) (*http.Response, error) {
 //
 // The method returns nil response and nil error when the response does not
 // exist in the cache or when it must be refreshed.
-func (c *Cache) load(key uint64, req *http.Request) (*http.Response, error) {
+func (c *Cache) load(client Client, key uint64, req *http.Request) (*http.Response, error) {
 	var ctx = req.Context()
 
 	buf, err := c.storage.Load(ctx, key)
@@ -202,7 +280,7 @@ func (c *Cache) load(key uint64, req *http.Request) (*http.Response, error) {
 		return resp, nil
 
 	case stale:
-		return c.verify(ctx, key, resp)
+		return c.verify(ctx, client, key, resp)
 	}
 
 	return nil, nil
@@ -211,7 +289,7 @@ func (c *Cache) load(key uint64, req *http.Request) (*http.Response, error) {
 // Verify verifies that the given response is still valid.
 //
 // https://tools.ietf.org/html/rfc7234#section-4.3.
-func (c *Cache) verify(ctx context.Context, key uint64, resp *http.Response) (*http.Response, error) {
+func (c *Cache) verify(ctx context.Context, client Client, key uint64, resp *http.Response) (*http.Response, error) {
 	var req = resp.Request.Clone(ctx)
 	var hdr = resp.Header
 
@@ -227,11 +305,13 @@ func (c *Cache) verify(ctx context.Context, key uint64, resp *http.Response) (*h
 		}
 	}
 
-	newresp, err := c.client.Do(req)
+	newresp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("antcache: validate %d - %w", key, err)
 	}
 
+	c.log(req, "antcache: validate", key, newresp.StatusCode)
+
 	// If a cache receives a 5xx (Server Error) response while
 	// attempting to validate a response, it can either forward this
 	// response to the requesting client, or act as if the server failed
@@ -275,7 +355,8 @@ func (c *Cache) verify(ctx context.Context, key uint64, resp *http.Response) (*h
 // The method overwrites the response's body with a readcloser
 // that will write the response to the storage when it is closed.
 //
-// If the response body is not closed, the response is never stored in the cache.
+// If the response body is not read until EOF and closed,
+// the response is never stored in the cache.
 func (c *Cache) store(key uint64, resp *http.Response) {
 	rc := resp.Body
 
@@ -283,6 +364,7 @@ func (c *Cache) store(key uint64, resp *http.Response) {
 		resp:  resp,
 		key:   key,
 		rc:    rc,
+		max:   c.maxSize,
 		ctx:   resp.Request.Context(),
 		store: c.storage.Store,
 	}
@@ -290,6 +372,16 @@ func (c *Cache) store(key uint64, resp *http.Response) {
 	return
 }
 
+// Log logs a cache event of req.
+func (c *Cache) log(req *http.Request, msg string, key uint64, status int) {
+	c.logger.DebugContext(req.Context(), msg,
+		"url", req.URL.String(),
+		"host", req.URL.Host,
+		"status", status,
+		"cache_key", key,
+	)
+}
+
 // Discard discasrds the given reader.
 func (c *Cache) discard(resp *http.Response) {
 	if resp != nil {
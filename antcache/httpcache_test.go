@@ -2,9 +2,11 @@ package antcache
 
 import (
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -92,6 +94,111 @@ func TestCache(t *testing.T) {
 		assert.Equal(uint64(1), srv.requests())
 	})
 
+	t.Run("does not cache partially read responses", func(t *testing.T) {
+		var assert = require.New(t)
+		var srv = server(t)
+		var req = request(t, srv.url)
+
+		c, err := New(http.DefaultClient)
+		assert.NoError(err)
+
+		resp, err := c.Do(req)
+		assert.NoError(err)
+		_, err = io.ReadFull(resp.Body, make([]byte, 10))
+		assert.NoError(err)
+		assert.NoError(resp.Body.Close())
+
+		resp, err = c.Do(req)
+		assert.NoError(err)
+		assert.Equal("0", resp.Header.Get("X-From-Cache"))
+		assert.Equal(uint64(2), srv.requests())
+		read(t, resp)
+	})
+
+	t.Run("does not cache responses larger than max size", func(t *testing.T) {
+		var assert = require.New(t)
+		var srv = server(t)
+		var req = request(t, srv.url)
+
+		c, err := New(http.DefaultClient, WithMaxSize(10))
+		assert.NoError(err)
+
+		resp, err := c.Do(req)
+		assert.NoError(err)
+		buf, err := io.ReadAll(resp.Body)
+		assert.NoError(err)
+		assert.Contains(string(buf), "<title>Example</title>")
+		assert.NoError(resp.Body.Close())
+
+		resp, err = c.Do(req)
+		assert.NoError(err)
+		assert.Equal("0", resp.Header.Get("X-From-Cache"))
+		assert.Equal(uint64(2), srv.requests())
+		read(t, resp)
+	})
+
+	t.Run("new invalid max size", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := New(http.DefaultClient, WithMaxSize(0))
+
+		assert.EqualError(err, `antcache: max size must be greater than 0`)
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		var assert = require.New(t)
+		var srv = server(t)
+		var req = request(t, srv.url)
+
+		c, err := New(http.DefaultClient, WithStorage(&memstore{}))
+		assert.NoError(err)
+
+		resp, err := c.Wrap(http.DefaultClient).Do(req)
+		assert.NoError(err)
+		assert.Equal(200, resp.StatusCode)
+		read(t, resp)
+
+		resp, err = c.Wrap(http.DefaultClient).Do(req)
+		assert.NoError(err)
+		assert.Equal("1", resp.Header.Get("X-From-Cache"))
+		assert.Equal(uint64(1), srv.requests())
+		read(t, resp)
+	})
+
+	t.Run("logs hits and misses", func(t *testing.T) {
+		var assert = require.New(t)
+		var srv = server(t)
+		var req = request(t, srv.url)
+		var buf strings.Builder
+		var logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+
+		c, err := New(http.DefaultClient, WithLogger(logger))
+		assert.NoError(err)
+
+		resp, err := c.Do(req)
+		assert.NoError(err)
+		read(t, resp)
+
+		resp, err = c.Do(req)
+		assert.NoError(err)
+		read(t, resp)
+
+		var key = strconv.FormatUint(keyof(req), 10)
+		assert.Contains(buf.String(), `msg="antcache: miss" url=`+srv.url)
+		assert.Contains(buf.String(), `msg="antcache: hit" url=`+srv.url)
+		assert.Contains(buf.String(), "status=200 cache_key="+key+"\n")
+	})
+
+	t.Run("new nil logger", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := New(http.DefaultClient, WithLogger(nil))
+
+		assert.EqualError(err, `antcache: logger must be non-nil`)
+	})
+
 	t.Run("verifies a cached response", func(t *testing.T) {
 		var assert = require.New(t)
 		var srv = server(t)
@@ -12,6 +12,7 @@
 package antcdp
 
 import (
+	"log/slog"
 	"net/http"
 	"sync"
 
@@ -39,6 +40,13 @@ type Client struct {
 	// If empty, it defaults to `antcdp.Addr`.
 	Addr string
 
+	// Logger is the logger to use.
+	//
+	// The client logs every request at debug level.
+	//
+	// If nil, the client does not log.
+	Logger *slog.Logger
+
 	// Transport is initialized on the 1st request.
 	transport *transport
 	once      sync.Once
@@ -48,7 +56,8 @@ type Client struct {
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	c.once.Do(func() {
 		c.transport = &transport{
-			pool: newTargets(devtool.New(c.addr())),
+			pool:   newTargets(devtool.New(c.addr())),
+			logger: c.logger(),
 		}
 	})
 
@@ -62,3 +71,11 @@ func (c *Client) addr() string {
 	}
 	return Addr
 }
+
+// Logger returns the logger.
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
@@ -1,7 +1,9 @@
 package antcdp
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 )
@@ -16,7 +18,8 @@ import (
 //
 // A transport is safe to use from multiple goroutines.
 type transport struct {
-	pool *targets
+	pool   *targets
+	logger *slog.Logger
 }
 
 // Roundtrip performs a roundtrip.
@@ -42,10 +45,18 @@ func (t *transport) roundtrip(req *http.Request) (*http.Response, error) {
 		err = multierror.Append(err, tx.close())
 	}()
 
+	start := time.Now()
 	resp, err := tx.do(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	t.logger.DebugContext(ctx, "antcdp: roundtrip",
+		"url", req.URL.String(),
+		"host", req.URL.Host,
+		"status", resp.StatusCode,
+		"duration", time.Since(start),
+	)
+
 	return resp, nil
 }
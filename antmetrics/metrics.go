@@ -0,0 +1,232 @@
+// Package antmetrics implements crawl metrics.
+//
+// Usage:
+//
+//	var metrics = antmetrics.New()
+//
+//	eng, err := ant.NewEngine(ant.EngineConfig{
+//	  Scraper: scraper,
+//	  Hooks:   metrics.Hooks(),
+//	})
+//
+//	http.Handle("/metrics", metrics)
+package antmetrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/yields/ant"
+)
+
+// Classes are the status classes pages are counted by.
+//
+// Responses that were not received are counted as "error".
+var classes = [...]string{"error", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// Buckets are the fetch latency buckets in seconds.
+var buckets = [...]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects crawl metrics.
+//
+// The metrics are collected using the engine's hooks and
+// are served in the Prometheus text exposition format.
+type Metrics struct {
+	pages      [len(classes)]atomic.Int64
+	bytes      atomic.Int64
+	latency    histogram
+	retries    atomic.Int64
+	throttles  atomic.Int64
+	robots     atomic.Int64
+	enqueued   atomic.Int64
+	dequeued   atomic.Int64
+	dropped    atomic.Int64
+	evicted    atomic.Int64
+	deduped    atomic.Int64
+	duplicates atomic.Int64
+	cacheHits  atomic.Int64
+	cacheMiss  atomic.Int64
+}
+
+// New returns new metrics.
+func New() *Metrics {
+	return &Metrics{}
+}
+
+// Hooks returns the engine hooks that collect the metrics.
+func (m *Metrics) Hooks() ant.Hooks {
+	return ant.Hooks{
+		OnEnqueue: func(_ context.Context, urls ant.URLs) {
+			m.enqueued.Add(int64(len(urls)))
+		},
+		OnDequeue: func(context.Context, *ant.URL) {
+			m.dequeued.Add(1)
+		},
+		OnRobotsDisallowed: func(context.Context, *ant.URL) {
+			m.robots.Add(1)
+		},
+		OnFetchDone: func(_ context.Context, _ *ant.URL, status int, n int64, d time.Duration) {
+			m.pages[class(status)].Add(1)
+			m.bytes.Add(n)
+			m.latency.observe(d)
+		},
+		OnPage: func(_ context.Context, p *ant.Page) {
+			switch p.Header.Get("X-From-Cache") {
+			case "1":
+				m.cacheHits.Add(1)
+			case "0":
+				m.cacheMiss.Add(1)
+			}
+		},
+		OnRetry: func(context.Context, *ant.URL, int, error) {
+			m.retries.Add(1)
+		},
+		OnThrottle: func(context.Context, *ant.URL, time.Duration) {
+			m.throttles.Add(1)
+		},
+		OnDedupe: func(_ context.Context, urls ant.URLs) {
+			m.deduped.Add(int64(len(urls)))
+		},
+		OnDiscard: func(_ context.Context, urls ant.URLs, reason ant.Discard) {
+			switch reason {
+			case ant.DiscardDuplicate:
+				m.duplicates.Add(int64(len(urls)))
+			case ant.DiscardEvicted:
+				m.evicted.Add(int64(len(urls)))
+			}
+		},
+		OnDrop: func(_ context.Context, urls ant.URLs) {
+			m.dropped.Add(int64(len(urls)))
+		},
+	}
+}
+
+// ServeHTTP implementation.
+//
+// The method writes all metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all metrics to w in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var p = &printer{w: w}
+
+	p.header("ant_pages_total", "counter", "Pages fetched by status class.")
+	for j, c := range classes {
+		p.printf("ant_pages_total{class=%q} %d\n", c, m.pages[j].Load())
+	}
+
+	p.header("ant_bytes_total", "counter", "Body bytes downloaded.")
+	p.printf("ant_bytes_total %d\n", m.bytes.Load())
+
+	p.header("ant_fetch_duration_seconds", "histogram", "Fetch latency in seconds.")
+	m.latency.write(p, "ant_fetch_duration_seconds")
+
+	p.header("ant_retries_total", "counter", "Retried fetch, scrape and dedupe attempts.")
+	p.printf("ant_retries_total %d\n", m.retries.Load())
+
+	p.header("ant_throttles_total", "counter", "Hosts throttled after a 429 or 503 response.")
+	p.printf("ant_throttles_total %d\n", m.throttles.Load())
+
+	p.header("ant_robots_disallowed_total", "counter", "URLs disallowed by robots.txt.")
+	p.printf("ant_robots_disallowed_total %d\n", m.robots.Load())
+
+	p.header("ant_queue_depth", "gauge", "URLs queued but not dequeued yet.")
+	p.printf("ant_queue_depth %d\n", m.enqueued.Load()-m.dequeued.Load()-m.dropped.Load()-m.evicted.Load())
+
+	p.header("ant_dropped_total", "counter", "Queued URLs that were not visited when the crawl stopped.")
+	p.printf("ant_dropped_total %d\n", m.dropped.Load())
+
+	p.header("ant_dedupe_hits_total", "counter", "URLs discarded as duplicates.")
+	p.printf("ant_dedupe_hits_total %d\n", m.duplicates.Load())
+
+	p.header("ant_dedupe_hit_ratio", "gauge", "Ratio of de-duplicated URLs that were duplicates.")
+	p.printf("ant_dedupe_hit_ratio %s\n", ratio(m.duplicates.Load(), m.deduped.Load()))
+
+	p.header("ant_cache_hits_total", "counter", "Pages served from cache.")
+	p.printf("ant_cache_hits_total %d\n", m.cacheHits.Load())
+
+	p.header("ant_cache_misses_total", "counter", "Pages not found in cache.")
+	p.printf("ant_cache_misses_total %d\n", m.cacheMiss.Load())
+
+	return p.n, p.err
+}
+
+// Class returns the index of the status class.
+func class(status int) int {
+	if status < 100 || status >= 600 {
+		return 0
+	}
+	return status / 100
+}
+
+// Ratio returns n / total formatted.
+func ratio(n, total int64) string {
+	if total == 0 {
+		return "0"
+	}
+	return format(float64(n) / float64(total))
+}
+
+// Format formats a float.
+func format(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Histogram implements a fixed bucket histogram.
+type histogram struct {
+	counts [len(buckets)]atomic.Int64
+	count  atomic.Int64
+	sum    atomic.Int64
+}
+
+// Observe observes duration d.
+func (h *histogram) observe(d time.Duration) {
+	for j, b := range buckets {
+		if d.Seconds() <= b {
+			h.counts[j].Add(1)
+		}
+	}
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Write writes the histogram to p.
+func (h *histogram) write(p *printer, name string) {
+	for j, b := range buckets {
+		p.printf("%s_bucket{le=%q} %d\n", name, format(b), h.counts[j].Load())
+	}
+	p.printf("%s_bucket{le=\"+Inf\"} %d\n", name, h.count.Load())
+	p.printf("%s_sum %s\n", name, format(time.Duration(h.sum.Load()).Seconds()))
+	p.printf("%s_count %d\n", name, h.count.Load())
+}
+
+// Printer writes formatted lines and
+// records the first error.
+type printer struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// Header prints the metric's help and type.
+func (p *printer) header(name, typ, help string) {
+	p.printf("# HELP %s %s\n", name, help)
+	p.printf("# TYPE %s %s\n", name, typ)
+}
+
+// Printf implementation.
+func (p *printer) printf(format string, args ...any) {
+	if p.err == nil {
+		n, err := fmt.Fprintf(p.w, format, args...)
+		p.n += int64(n)
+		p.err = err
+	}
+}
@@ -0,0 +1,138 @@
+package antmetrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yields/ant"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("hooks", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var m = New()
+		var h = m.Hooks()
+		var u = &ant.URL{Scheme: "https", Host: "example.com"}
+
+		h.OnEnqueue(ctx, ant.URLs{u, u, u, u})
+		h.OnDequeue(ctx, u)
+		h.OnFetchDone(ctx, u, 200, 10, 20*time.Millisecond)
+		h.OnFetchDone(ctx, u, 503, 5, 2*time.Second)
+		h.OnFetchDone(ctx, u, 0, 0, time.Millisecond)
+		h.OnRetry(ctx, u, 1, nil)
+		h.OnThrottle(ctx, u, time.Second)
+		h.OnRobotsDisallowed(ctx, u)
+		h.OnDedupe(ctx, ant.URLs{u, u, u, u})
+		h.OnDiscard(ctx, ant.URLs{u}, ant.DiscardDuplicate)
+		h.OnDiscard(ctx, ant.URLs{u, u}, ant.DiscardMatcher)
+		h.OnDiscard(ctx, ant.URLs{u}, ant.DiscardEvicted)
+		h.OnDrop(ctx, ant.URLs{u})
+		h.OnPage(ctx, &ant.Page{Header: http.Header{"X-From-Cache": {"1"}}})
+		h.OnPage(ctx, &ant.Page{Header: http.Header{"X-From-Cache": {"0"}}})
+		h.OnPage(ctx, &ant.Page{Header: http.Header{}})
+
+		var buf strings.Builder
+		_, err := m.WriteTo(&buf)
+		assert.NoError(err)
+
+		for _, line := range []string{
+			`ant_pages_total{class="2xx"} 1`,
+			`ant_pages_total{class="5xx"} 1`,
+			`ant_pages_total{class="error"} 1`,
+			`ant_bytes_total 15`,
+			`ant_fetch_duration_seconds_bucket{le="0.005"} 1`,
+			`ant_fetch_duration_seconds_bucket{le="0.025"} 2`,
+			`ant_fetch_duration_seconds_bucket{le="2.5"} 3`,
+			`ant_fetch_duration_seconds_bucket{le="+Inf"} 3`,
+			`ant_fetch_duration_seconds_sum 2.021`,
+			`ant_fetch_duration_seconds_count 3`,
+			`ant_retries_total 1`,
+			`ant_throttles_total 1`,
+			`ant_robots_disallowed_total 1`,
+			`ant_queue_depth 1`,
+			`ant_dropped_total 1`,
+			`ant_dedupe_hits_total 1`,
+			`ant_dedupe_hit_ratio 0.25`,
+			`ant_cache_hits_total 1`,
+			`ant_cache_misses_total 1`,
+			`# TYPE ant_fetch_duration_seconds histogram`,
+		} {
+			assert.Contains(buf.String(), line+"\n")
+		}
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		var assert = require.New(t)
+		var m = New()
+		var rec = httptest.NewRecorder()
+
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+		assert.Equal(200, rec.Code)
+		assert.Equal("text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(rec.Body.String(), "ant_pages_total{class=\"2xx\"} 0\n")
+		assert.Contains(rec.Body.String(), "ant_dedupe_hit_ratio 0\n")
+	})
+
+	t.Run("engine", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var m = New()
+		var srv = httptest.NewServer(http.FileServer(http.Dir("../testdata/example.com")))
+		t.Cleanup(srv.Close)
+
+		eng, err := ant.NewEngine(ant.EngineConfig{
+			Scraper: scraper{},
+			Hooks:   m.Hooks(),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+
+		var buf strings.Builder
+		m.WriteTo(&buf)
+
+		assert.Contains(buf.String(), `ant_pages_total{class="2xx"} 5`+"\n")
+		assert.Contains(buf.String(), "ant_robots_disallowed_total 1\n")
+		assert.Contains(buf.String(), "ant_queue_depth 0\n")
+		assert.Contains(buf.String(), "ant_dedupe_hits_total 2\n")
+		assert.Contains(buf.String(), "ant_cache_misses_total 0\n")
+	})
+
+	t.Run("engine halted", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var m = New()
+		var srv = httptest.NewServer(http.FileServer(http.Dir("../testdata/example.com")))
+		t.Cleanup(srv.Close)
+
+		eng, err := ant.NewEngine(ant.EngineConfig{
+			Scraper:  scraper{},
+			Hooks:    m.Hooks(),
+			MaxPages: 1,
+			Impolite: true,
+		})
+		assert.NoError(err)
+		assert.Error(eng.Run(ctx, srv.URL, srv.URL+"/a.html", srv.URL+"/b.html"))
+
+		var buf strings.Builder
+		m.WriteTo(&buf)
+
+		assert.Contains(buf.String(), `ant_pages_total{class="2xx"} 1`+"\n")
+		assert.Contains(buf.String(), "ant_queue_depth 0\n")
+		assert.Contains(buf.String(), "ant_dropped_total 2\n")
+	})
+}
+
+// Scraper follows all links.
+type scraper struct{}
+
+// Scrape implementation.
+func (scraper) Scrape(ctx context.Context, p *ant.Page) (ant.URLs, error) {
+	return p.URLs(), nil
+}
@@ -0,0 +1,53 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Backoff waits before the next attempt.
+//
+// The duration grows quadratically with the attempt,
+// starting at min and capped at max.
+func backoff(ctx context.Context, attempt int, min, max time.Duration) error {
+	if min >= max {
+		return fmt.Errorf("ant: min backoff must be less than max backoff")
+	}
+
+	dur, _ := quadratic{min, max}.Retry(Attempt{Number: attempt})
+	return sleep(ctx, dur)
+}
+
+// Sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsTemporary returns true if the error is temporary.
+//
+// Only err itself is checked, the fetcher wraps network errors
+// which keeps temporary network errors from being retried.
+func isTemporary(err error) bool {
+	t, ok := err.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+// WrapsTemporary returns true if err or an error it wraps is temporary.
+//
+// The engine uses it for scraper and deduper errors, the error
+// chain is searched for an error that implements a `Temporary() bool`
+// method.
+func wrapsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
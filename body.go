@@ -0,0 +1,104 @@
+package ant
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrBodyTooLarge is returned when a response body
+	// exceeds the fetcher's `MaxBodySize`.
+	ErrBodyTooLarge = errors.New("ant: body too large")
+
+	// ErrBodyTimeout is returned when no body data is
+	// received within the fetcher's `IdleTimeout`.
+	ErrBodyTimeout = errors.New("ant: body read timeout")
+)
+
+// Body guards a response body.
+//
+// The body limits the amount of bytes that can be read and
+// closes the underlying body when a read blocks for too long.
+type body struct {
+	rc        io.ReadCloser
+	max       int64
+	truncate  bool
+	idle      time.Duration
+	n         int64
+	err       error
+	timer     *time.Timer
+	timedout  atomic.Bool
+	truncated atomic.Bool
+}
+
+// NewBody returns a new body that guards rc.
+//
+// If max <= 0 the size is not limited, if idle <= 0
+// reads do not time out.
+func newBody(rc io.ReadCloser, max int64, truncate bool, idle time.Duration) *body {
+	var b = &body{
+		rc:       rc,
+		max:      max,
+		truncate: truncate,
+		idle:     idle,
+	}
+
+	if idle > 0 {
+		b.timer = time.AfterFunc(idle, func() {
+			b.timedout.Store(true)
+			b.rc.Close()
+		})
+		b.timer.Stop()
+	}
+
+	return b
+}
+
+// Read implementation.
+func (b *body) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Read a single byte past the limit, which
+	// tells apart bodies that are exactly max bytes.
+	if b.max > 0 && int64(len(p)) > b.max+1-b.n {
+		p = p[:b.max+1-b.n]
+	}
+
+	if b.timer != nil {
+		b.timer.Reset(b.idle)
+	}
+
+	n, err := b.rc.Read(p)
+
+	if b.timer != nil && !b.timer.Stop() && b.timedout.Load() {
+		err = ErrBodyTimeout
+	}
+
+	if b.n += int64(n); b.max > 0 && b.n > b.max {
+		n -= int(b.n - b.max)
+		b.n = b.max
+		err = ErrBodyTooLarge
+		if b.truncate {
+			b.truncated.Store(true)
+			err = io.EOF
+		}
+	}
+
+	if err != nil {
+		b.err = err
+	}
+
+	return n, err
+}
+
+// Close implementation.
+func (b *body) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.rc.Close()
+}
@@ -0,0 +1,177 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBody(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, -1, time.Millisecond)
+		var fetcher = &Fetcher{MaxBodySize: 1000}
+
+		page, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		defer page.close()
+
+		_, err = page.Bytes()
+		assert.True(errors.Is(err, ErrBodyTooLarge))
+		assert.False(page.Truncated())
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, -1, time.Millisecond)
+		var fetcher = &Fetcher{MaxBodySize: 1000, TruncateBody: true}
+
+		page, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		defer page.close()
+
+		buf, err := page.Bytes()
+		assert.NoError(err)
+		assert.Len(buf, 1000)
+		assert.True(page.Truncated())
+	})
+
+	t.Run("exact size", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, 10, time.Millisecond)
+		var fetcher = &Fetcher{MaxBodySize: 1000, TruncateBody: true}
+
+		page, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		defer page.close()
+
+		buf, err := page.Bytes()
+		assert.NoError(err)
+		assert.Len(buf, 1000)
+		assert.False(page.Truncated())
+	})
+
+	t.Run("reject content length", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var fetcher = &Fetcher{MaxBodySize: 1000}
+		var url = serve(t, func(w http.ResponseWriter) {
+			w.Header().Set("Content-Length", strconv.Itoa(2000))
+			w.Write([]byte(strings.Repeat("a", 2000)))
+		})
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.True(errors.Is(err, ErrBodyTooLarge))
+	})
+
+	t.Run("idle timeout", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, 5, 200*time.Millisecond)
+		var fetcher = &Fetcher{IdleTimeout: 20 * time.Millisecond}
+
+		page, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		defer page.close()
+
+		_, err = page.Bytes()
+		assert.True(errors.Is(err, ErrBodyTimeout))
+	})
+
+	t.Run("engine", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, -1, time.Millisecond)
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper:  &visitor{},
+			Fetcher:  &Fetcher{MaxBodySize: 1000},
+			Impolite: true,
+		})
+		assert.NoError(err)
+
+		err = eng.Run(ctx, url.String())
+		assert.True(errors.Is(err, ErrBodyTooLarge))
+	})
+
+	t.Run("engine skips scrape", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, -1, time.Millisecond)
+		var calls atomic.Int64
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				calls.Add(1)
+				return nil, nil
+			}),
+			Fetcher:  &Fetcher{MaxBodySize: 1000},
+			Impolite: true,
+		})
+		assert.NoError(err)
+
+		err = eng.Run(ctx, url.String())
+		assert.True(errors.Is(err, ErrBodyTooLarge))
+		assert.Zero(calls.Load())
+	})
+
+	t.Run("engine drain errors", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var url = drip(t, 20, time.Millisecond)
+		var calls atomic.Int64
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				calls.Add(1)
+				return nil, nil
+			}),
+			Fetcher:  &Fetcher{MaxBodySize: 1000, MaxBufferSize: 200},
+			Impolite: true,
+		})
+		assert.NoError(err)
+
+		err = eng.Run(ctx, url.String())
+		assert.True(errors.Is(err, ErrBodyTooLarge))
+		assert.Equal(int64(1), calls.Load())
+	})
+}
+
+// Drip serves a body of n 100 byte chunks, one chunk every d.
+//
+// If n < 0 the body never ends.
+func drip(t testing.TB, n int, d time.Duration) *URL {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var chunk = []byte(strings.Repeat("a", 100))
+
+		w.Header().Set("Content-Type", "text/html")
+		for j := 0; n < 0 || j < n; j++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(d):
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return parseURL(t, srv.URL)
+}
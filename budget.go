@@ -0,0 +1,140 @@
+package ant
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBudgetExceeded is returned when a crawl budget is exceeded.
+//
+// The engine returns a `*BudgetError` that wraps the error, use
+// `errors.Is(err, ant.ErrBudgetExceeded)` to check for it.
+var ErrBudgetExceeded = errors.New("ant: budget exceeded")
+
+// Budget enumerates crawl budgets.
+type Budget string
+
+// All budgets that stop the engine.
+const (
+	BudgetPages    Budget = "pages"
+	BudgetDuration Budget = "duration"
+	BudgetBytes    Budget = "bytes"
+)
+
+// BudgetError represents a budget error.
+//
+// The error is returned from `Engine.Run()` when
+// one of the configured budgets runs out.
+type BudgetError struct {
+	Budget Budget
+}
+
+// Error implementation.
+func (err *BudgetError) Error() string {
+	return fmt.Sprintf("ant: %s budget exceeded", err.Budget)
+}
+
+// Unwrap implementation.
+func (err *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Budgets tracks all crawl budgets.
+type budgets struct {
+	maxPages     int64
+	maxBytes     int64
+	maxHostPages int
+	pages        atomic.Int64
+	bytes        atomic.Int64
+	hostsmu      sync.Mutex
+	hosts        map[string]int
+}
+
+// Reserve reserves a single page.
+//
+// The method returns false if the pages budget ran out.
+func (b *budgets) reserve() bool {
+	if b.maxPages <= 0 {
+		return true
+	}
+	if b.pages.Add(1) > b.maxPages {
+		b.pages.Add(-1)
+		return false
+	}
+	return true
+}
+
+// Release releases a reserved page.
+func (b *budgets) release() {
+	if b.maxPages > 0 {
+		b.pages.Add(-1)
+	}
+}
+
+// Download records n downloaded bytes.
+//
+// The method returns false if the bytes budget ran out.
+func (b *budgets) download(n int64) bool {
+	if b.bytes.Add(n); b.maxBytes > 0 {
+		return b.bytes.Load() < b.maxBytes
+	}
+	return true
+}
+
+// Filter returns all URLs whose host did not reach its page cap.
+func (b *budgets) filter(urls URLs) URLs {
+	if b.maxHostPages <= 0 {
+		return urls
+	}
+
+	b.hostsmu.Lock()
+	defer b.hostsmu.Unlock()
+
+	var ret = make(URLs, 0, len(urls))
+
+	for _, u := range urls {
+		if b.hosts[u.Host] < b.maxHostPages {
+			b.hosts[u.Host]++
+			ret = append(ret, u)
+		}
+	}
+
+	return ret
+}
+
+// Counter counts the bytes read from a body.
+//
+// The counter keeps the first read error
+// other than `io.EOF`.
+type counter struct {
+	rc  io.ReadCloser
+	n   int64
+	err error
+}
+
+// Read implementation.
+func (c *counter) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.n += int64(n)
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// Close implementation.
+func (c *counter) Close() error {
+	return c.rc.Close()
+}
+
+// Guarded returns the read error if the body exceeded
+// the fetcher's size limit or timed out.
+func (c *counter) guarded() error {
+	if errors.Is(c.err, ErrBodyTooLarge) || errors.Is(c.err, ErrBodyTimeout) {
+		return c.err
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package ant
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Checkpoint represents a serialized crawl state.
+type checkpoint struct {
+	Queued   []checkpointURL `json:"queued"`
+	Inflight []checkpointURL `json:"inflight"`
+	Deduper  []byte          `json:"deduper,omitempty"`
+}
+
+// CheckpointURL represents a serialized URL.
+type checkpointURL struct {
+	URL      string         `json:"url"`
+	Depth    int            `json:"depth,omitempty"`
+	Referrer string         `json:"referrer,omitempty"`
+	Method   string         `json:"method,omitempty"`
+	Header   http.Header    `json:"header,omitempty"`
+	Body     []byte         `json:"body,omitempty"`
+	Priority float64        `json:"priority,omitempty"`
+	Session  string         `json:"session,omitempty"`
+	Meta     map[string]any `json:"meta,omitempty"`
+}
+
+// Tracker tracks all URLs that were queued
+// but not handled yet by the engine.
+//
+// The tracker is independent of the queue implementation
+// which allows the engine to checkpoint its state even after
+// the queue was closed.
+//
+// A URL can be queued more than once, e.g. with a GET and
+// a POST request, each entry has its own URL pointer which
+// in-memory queues hand out as is, dequeued and evicted URLs
+// are matched to their exact entry. URLs of queues that do
+// not keep the pointers, e.g. durable queues, are matched to
+// their entries in the order they were added.
+type tracker struct {
+	mu   sync.Mutex
+	seq  uint64
+	urls map[string][]*tracked
+}
+
+// Meta represents URL metadata.
+//
+// The metadata describes how a URL was discovered, the depth
+// is the amount of hops from the initial URLs and the referrer
+// is the URL of the page that linked to it, req is the request
+// the URL was queued with, if any.
+type meta struct {
+	depth    int
+	referrer *URL
+	req      *Request
+}
+
+// Request returns the request to fetch url with.
+func (m meta) request(url *URL) *Request {
+	if m.req != nil {
+		return m.req
+	}
+	return NewRequest(url)
+}
+
+// Tracked represents a tracked URL.
+//
+// An entry is queued once the queue accepted it, entries that
+// are added after the queue was closed remain pending but
+// were never queued.
+type tracked struct {
+	meta
+	seq      uint64
+	url      *URL
+	queued   bool
+	inflight bool
+}
+
+// NewTracker returns a new tracker.
+func newTracker() *tracker {
+	return &tracker{
+		urls: make(map[string][]*tracked),
+	}
+}
+
+// Add adds the given URL as pending with metadata m.
+func (t *tracker) add(u *URL, m meta) *tracked {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v := &tracked{
+		meta: m,
+		seq:  t.seq + 1,
+		url:  u,
+	}
+
+	t.seq++
+	t.urls[u.String()] = append(t.urls[u.String()], v)
+	return v
+}
+
+// Queue marks the given entries as queued.
+func (t *tracker) queue(vs []*tracked) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, v := range vs {
+		v.queued = true
+	}
+}
+
+// Evict removes the queued entry of the given URL.
+//
+// The method is called with URLs that a bounded queue evicted,
+// they are never dequeued, if the URL is not tracked the method
+// is a no-op.
+func (t *tracker) evict(u *URL) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var key = u.String()
+	var all = t.urls[key]
+
+	if j, ok := find(all, u, func(v *tracked) bool {
+		return v.queued && !v.inflight
+	}); ok {
+		all = append(all[:j:j], all[j+1:]...)
+	}
+
+	if len(all) == 0 {
+		delete(t.urls, key)
+	} else {
+		t.urls[key] = all
+	}
+}
+
+// Start marks the queued entry of the given URL as in-flight.
+//
+// If the URL is not tracked, for example when it was delivered by
+// a durable queue after a restart, the method returns a new entry
+// with zero metadata.
+func (t *tracker) start(u *URL) *tracked {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var all = t.urls[u.String()]
+
+	if j, ok := find(all, u, func(v *tracked) bool {
+		return !v.inflight
+	}); ok {
+		all[j].inflight = true
+		return all[j]
+	}
+
+	return &tracked{url: u, inflight: true}
+}
+
+// Find returns the index of the entry of u that matches ok.
+//
+// The entry that holds the URL pointer u is preferred, when no
+// entry holds it the first entry that matches ok is returned.
+func find(all []*tracked, u *URL, ok func(*tracked) bool) (int, bool) {
+	var first = -1
+
+	for j, v := range all {
+		if !ok(v) {
+			continue
+		}
+		if v.url == u {
+			return j, true
+		}
+		if first == -1 {
+			first = j
+		}
+	}
+
+	return first, first != -1
+}
+
+// Done removes the given in-flight entry.
+//
+// Other entries of the same URL, e.g. when the URL was
+// queued again while it was in-flight, remain tracked.
+func (t *tracker) done(v *tracked) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var key = v.url.String()
+	var all = t.urls[key]
+
+	for j, w := range all {
+		if w == v {
+			all = append(all[:j:j], all[j+1:]...)
+			break
+		}
+	}
+
+	if len(all) == 0 {
+		delete(t.urls, key)
+	} else {
+		t.urls[key] = all
+	}
+}
+
+// Queued returns all URLs that are queued but not in-flight.
+func (t *tracker) queued() URLs {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var ret URLs
+
+	for _, all := range t.urls {
+		for _, v := range all {
+			if v.queued && !v.inflight {
+				ret = append(ret, v.url)
+			}
+		}
+	}
+
+	return ret
+}
+
+// Snapshot returns the queued and in-flight URLs
+// in the order they were added.
+func (t *tracker) snapshot() (queued, inflight []checkpointURL) {
+	t.mu.Lock()
+	var all = make([]*tracked, 0, len(t.urls))
+	for _, v := range t.urls {
+		all = append(all, v...)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].seq < all[j].seq
+	})
+
+	for _, v := range all {
+		u := checkpointURL{
+			URL:   v.url.String(),
+			Depth: v.depth,
+		}
+
+		if v.referrer != nil {
+			u.Referrer = v.referrer.String()
+		}
+
+		if r := v.req; r != nil {
+			u.Method = r.Method
+			u.Header = r.Header
+			u.Body = r.Body
+			u.Priority = r.Priority
+			u.Session = r.Session
+			u.Meta = r.Meta
+		}
+
+		if v.inflight {
+			inflight = append(inflight, u)
+		} else {
+			queued = append(queued, u)
+		}
+	}
+
+	return queued, inflight
+}
+
+// Checkpoint writes the engine's crawl state to w.
+//
+// The state contains all queued URLs, URLs that were in-flight
+// and the deduper's state, it can be passed as `EngineConfig.Resume`
+// to a new engine to continue the crawl from the same point.
+//
+// The method is meant to be called once `Run()` returns, typically
+// after the context was canceled, in-flight URLs that were canceled
+// are visited again when the crawl is resumed.
+//
+// The deduper's state is included only if it implements
+// `encoding.BinaryMarshaler`, both `DedupeMap()` and `DedupeBF()` do,
+// request metadata is stored as plain JSON, see `Request.Meta`.
+func (eng *Engine) Checkpoint(w io.Writer) error {
+	var cp checkpoint
+
+	cp.Queued, cp.Inflight = eng.pending.snapshot()
+
+	if m, ok := eng.deduper.(encoding.BinaryMarshaler); ok {
+		buf, err := m.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("ant: checkpoint deduper - %w", err)
+		}
+		cp.Deduper = buf
+	}
+
+	if err := json.NewEncoder(w).Encode(cp); err != nil {
+		return fmt.Errorf("ant: checkpoint encode - %w", err)
+	}
+
+	return nil
+}
+
+// Restore restores a checkpoint from r.
+//
+// The method restores the deduper's state and returns
+// all URLs that should be queued, in-flight URLs first.
+func restore(r io.Reader, d Deduper) ([]*tracked, error) {
+	var cp checkpoint
+
+	if err := json.NewDecoder(r).Decode(&cp); err != nil {
+		return nil, fmt.Errorf("ant: resume decode - %w", err)
+	}
+
+	if len(cp.Deduper) > 0 {
+		u, ok := d.(encoding.BinaryUnmarshaler)
+		if !ok {
+			return nil, fmt.Errorf("ant: resume deduper %T does not implement encoding.BinaryUnmarshaler", d)
+		}
+		if err := u.UnmarshalBinary(cp.Deduper); err != nil {
+			return nil, fmt.Errorf("ant: resume deduper - %w", err)
+		}
+	}
+
+	var all = append(cp.Inflight, cp.Queued...)
+	var ret = make([]*tracked, 0, len(all))
+
+	for _, v := range all {
+		u, err := url.Parse(v.URL)
+		if err != nil {
+			return nil, fmt.Errorf("ant: resume parse url %q - %w", v.URL, err)
+		}
+
+		t := &tracked{url: u}
+		t.depth = v.Depth
+
+		if v.Referrer != "" {
+			if t.referrer, err = url.Parse(v.Referrer); err != nil {
+				return nil, fmt.Errorf("ant: resume parse url %q - %w", v.Referrer, err)
+			}
+		}
+
+		if v.Method != "" || v.Header != nil || v.Body != nil || v.Priority != 0 || v.Session != "" || v.Meta != nil {
+			t.req = &Request{
+				Method:   v.Method,
+				URL:      u,
+				Header:   v.Header,
+				Body:     v.Body,
+				Priority: v.Priority,
+				Session:  v.Session,
+				Depth:    v.Depth,
+				Meta:     v.Meta,
+			}
+		}
+
+		ret = append(ret, t)
+	}
+
+	return ret, nil
+}
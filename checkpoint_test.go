@@ -0,0 +1,231 @@
+package ant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint(t *testing.T) {
+	t.Run("resume", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var buf bytes.Buffer
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		first := &visitor{}
+		eng := setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			urls, _ := first.Scrape(ctx, p)
+			if p.URL.Path != "/" {
+				cancel()
+			}
+			return urls, nil
+		}))
+
+		err := eng.Run(ctx, srv.URL)
+		assert.True(errors.Is(err, context.Canceled))
+		assert.NoError(eng.Checkpoint(&buf))
+
+		second := &visitor{}
+		eng, err = NewEngine(EngineConfig{
+			Scraper: second,
+			Resume:  &buf,
+		})
+		assert.NoError(err)
+
+		err = eng.Run(context.Background(), srv.URL)
+		assert.NoError(err)
+
+		assert.NotContains(second.paths, "/")
+
+		all := append(first.paths, second.paths...)
+		sort.Strings(all)
+		all = compact(all)
+
+		expect := []string{
+			"/",
+			"/a.html",
+			"/about.html",
+			"/b.html",
+			"/products.html",
+		}
+
+		assert.Equal(expect, all)
+	})
+
+	t.Run("resume requests", func(t *testing.T) {
+		var assert = require.New(t)
+		var buf bytes.Buffer
+		var u = parseURL(t, "https://example.com/search")
+
+		var req = &Request{
+			Method:   "POST",
+			URL:      u,
+			Header:   http.Header{"X-Token": {"secret"}},
+			Body:     []byte("q=ant"),
+			Priority: 1.5,
+			Depth:    2,
+			Session:  "a",
+			Meta:     map[string]any{"q": "ant"},
+		}
+
+		eng := setup(t, &visitor{})
+		eng.pending.add(u, meta{depth: 2, req: req})
+		assert.NoError(eng.Checkpoint(&buf))
+
+		all, err := restore(&buf, DedupeMap())
+		assert.NoError(err)
+		assert.Len(all, 1)
+		assert.Equal(req, all[0].req)
+	})
+
+	t.Run("resume meta", func(t *testing.T) {
+		var assert = require.New(t)
+		var buf bytes.Buffer
+		var u = parseURL(t, "https://example.com/search")
+		var req = &Request{URL: u, Meta: map[string]any{"page": 2}}
+
+		eng := setup(t, &visitor{})
+		eng.pending.add(u, meta{req: req})
+		assert.NoError(eng.Checkpoint(&buf))
+
+		all, err := restore(&buf, DedupeMap())
+		assert.NoError(err)
+		assert.Len(all, 1)
+		assert.Equal(map[string]any{"page": float64(2)}, all[0].req.Meta)
+	})
+
+	t.Run("resume disk queue", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var dir = t.TempDir()
+		var buf bytes.Buffer
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		q, err := DiskQueue(dir)
+		assert.NoError(err)
+
+		first := &visitor{}
+		eng, err := NewEngine(EngineConfig{
+			Concurrency: 1,
+			Queue:       q,
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				urls, _ := first.Scrape(ctx, p)
+				if p.URL.Path != "/" {
+					cancel()
+				}
+				return urls, nil
+			}),
+		})
+		assert.NoError(err)
+
+		err = eng.Run(ctx, srv.URL)
+		assert.True(errors.Is(err, context.Canceled))
+		assert.NoError(eng.Checkpoint(&buf))
+
+		q, err = DiskQueue(dir)
+		assert.NoError(err)
+
+		second := &visitor{}
+		eng, err = NewEngine(EngineConfig{
+			Queue:   q,
+			Scraper: second,
+			Resume:  &buf,
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(context.Background(), srv.URL))
+
+		sort.Strings(second.paths)
+		assert.Equal(compact(second.paths), second.paths)
+		assert.NotContains(second.paths, "/")
+
+		all := append(first.paths, second.paths...)
+		sort.Strings(all)
+		all = compact(all)
+
+		expect := []string{
+			"/",
+			"/a.html",
+			"/about.html",
+			"/b.html",
+			"/products.html",
+		}
+
+		assert.Equal(expect, all)
+	})
+
+	t.Run("bloom filter", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var buf bytes.Buffer
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: &visitor{},
+			Deduper: DedupeBF(1000, 4),
+		})
+		assert.NoError(err)
+
+		_, err = eng.dedupe(ctx, parseURLs(t, "https://a"))
+		assert.NoError(err)
+		assert.NoError(eng.Checkpoint(&buf))
+
+		eng, err = NewEngine(EngineConfig{
+			Scraper: &visitor{},
+			Deduper: DedupeBF(1000, 4),
+			Resume:  &buf,
+		})
+		assert.NoError(err)
+
+		urls, err := eng.dedupe(ctx, parseURLs(t, "https://a", "https://b"))
+		assert.NoError(err)
+		assert.Equal(1, len(urls))
+		assert.Equal("https://b", urls[0].String())
+	})
+
+	t.Run("resume invalid", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := NewEngine(EngineConfig{
+			Scraper: &visitor{},
+			Resume:  strings.NewReader("{"),
+		})
+
+		assert.Error(err)
+		assert.Contains(err.Error(), `ant: resume decode`)
+	})
+
+	t.Run("resume unsupported deduper", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := NewEngine(EngineConfig{
+			Scraper: &visitor{},
+			Deduper: dedupeError{},
+			Resume:  strings.NewReader(`{"deduper":"Zm9v"}`),
+		})
+
+		assert.Error(err)
+		assert.Contains(err.Error(), `does not implement encoding.BinaryUnmarshaler`)
+	})
+}
+
+func compact(s []string) []string {
+	var ret []string
+	for j, v := range s {
+		if j == 0 || s[j-1] != v {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
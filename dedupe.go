@@ -2,6 +2,7 @@ package ant
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/willf/bloom"
@@ -55,9 +56,35 @@ func (d *deduper) Dedupe(ctx context.Context, urls URLs) (URLs, error) {
 	return ret, nil
 }
 
+// MarshalBinary implementation.
+//
+// The method encodes all visited URLs separated by newlines.
+func (d *deduper) MarshalBinary() ([]byte, error) {
+	var b strings.Builder
+
+	d.m.Range(func(k, _ any) bool {
+		b.WriteString(k.(string))
+		b.WriteByte('\n')
+		return true
+	})
+
+	return []byte(b.String()), nil
+}
+
+// UnmarshalBinary implementation.
+func (d *deduper) UnmarshalBinary(data []byte) error {
+	for _, k := range strings.Split(string(data), "\n") {
+		if k != "" {
+			d.m.Store(k, nil)
+		}
+	}
+	return nil
+}
+
 // Dedupebf implements a bloom filter deduper.
 type dedupebf struct {
 	filter *bloom.BloomFilter
+	mu     sync.Mutex
 }
 
 // DedupeBF returns a new deduper backed by bloom filter.
@@ -77,6 +104,9 @@ func DedupeBF(k, m uint) Deduper {
 func (d *dedupebf) Dedupe(ctx context.Context, urls URLs) (URLs, error) {
 	var ret = make(URLs, 0, len(urls))
 
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	for _, u := range urls {
 		v := []byte(u.String())
 		if !d.filter.Test(v) {
@@ -87,3 +117,19 @@ func (d *dedupebf) Dedupe(ctx context.Context, urls URLs) (URLs, error) {
 
 	return ret, nil
 }
+
+// MarshalBinary implementation.
+//
+// The method encodes the bloom filter's bit set.
+func (d *dedupebf) MarshalBinary() ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.filter.GobEncode()
+}
+
+// UnmarshalBinary implementation.
+func (d *dedupebf) UnmarshalBinary(data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.filter.GobDecode(data)
+}
@@ -0,0 +1,512 @@
+package ant
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// SegmentSize is the maximum amount of URLs
+	// that are written into a single segment.
+	segmentSize = 4096
+)
+
+// Segment represents a single on-disk segment.
+//
+// A segment consists of two files, `<base>.seg` which contains
+// all enqueued URLs and `<base>.ack` which contains the sequence
+// numbers of all acknowledged URLs.
+type segment struct {
+	base  uint64
+	count int
+	acked int
+	log   *os.File
+	ack   *os.File
+}
+
+// Full returns true if the segment is full.
+func (s *segment) full() bool {
+	return s.count >= segmentSize
+}
+
+// Entry represents a queued URL.
+type entry struct {
+	seq uint64
+	url *URL
+}
+
+// Diskqueue implements a durable on-disk queue.
+type diskqueue struct {
+	path     string
+	pending  []entry
+	inflight map[string][]uint64
+	segments map[uint64]*segment
+	active   *segment
+	seq      uint64
+	cond     *sync.Cond
+	stopped  bool
+	closed   bool
+	wg       *sync.WaitGroup
+}
+
+// DiskQueue opens a durable queue at path.
+//
+// The queue writes all enqueued URLs into an append-only log
+// of segments and records acknowledged URLs next to them, when
+// the queue is re-opened all URLs that were not acknowledged
+// are delivered again, including URLs that were dequeued but
+// never acknowledged with `Done()`.
+//
+// Enqueued URLs are fsynced before `Enqueue()` returns, acknowledgements
+// are not, so a crash may cause some URLs to be delivered twice.
+//
+// When a segment is full and all of its URLs are acknowledged
+// its files are removed, this keeps the disk usage bounded
+// by the amount of pending URLs.
+//
+// The path must be an existing directory, it is up to the caller
+// to ensure that it is not used by multiple queues at the same time.
+func DiskQueue(path string) (Queue, error) {
+	dq := &diskqueue{
+		path:     path,
+		inflight: make(map[string][]uint64),
+		segments: make(map[uint64]*segment),
+		cond:     sync.NewCond(&sync.Mutex{}),
+		wg:       &sync.WaitGroup{},
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("ant: diskqueue %w", err)
+	}
+
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("ant: diskqueue expected a directory")
+	}
+
+	if err := dq.load(); err != nil {
+		for _, seg := range dq.segments {
+			seg.close()
+		}
+		return nil, err
+	}
+
+	dq.wg.Add(len(dq.pending))
+	return dq, nil
+}
+
+// Load loads all segments from disk.
+func (dq *diskqueue) load() error {
+	names, err := filepath.Glob(filepath.Join(dq.path, "*.seg"))
+	if err != nil {
+		return fmt.Errorf("ant: diskqueue glob - %w", err)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		base, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), ".seg"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("ant: diskqueue invalid segment %q", name)
+		}
+
+		seg, entries, err := dq.open(base)
+		if err != nil {
+			return err
+		}
+
+		if seg.full() && seg.acked >= seg.count {
+			if err := dq.remove(seg); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dq.segments[base] = seg
+		dq.pending = append(dq.pending, entries...)
+		dq.seq = base + uint64(seg.count)
+
+		if !seg.full() {
+			dq.active = seg
+		}
+	}
+
+	return nil
+}
+
+// Open opens an existing segment by its base sequence.
+//
+// The method returns the segment and all its URLs that
+// were not acknowledged.
+func (dq *diskqueue) open(base uint64) (*segment, []entry, error) {
+	var seg = &segment{base: base}
+	var acked = make(map[uint64]bool)
+	var entries []entry
+
+	ack, err := os.OpenFile(dq.filename(base, "ack"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ant: diskqueue open - %w", err)
+	}
+	seg.ack = ack
+
+	r := bufio.NewReader(ack)
+	for {
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			break
+		}
+		acked[binary.BigEndian.Uint64(buf[:])] = true
+	}
+
+	log, err := os.OpenFile(dq.filename(base, "seg"), os.O_RDWR, 0644)
+	if err != nil {
+		ack.Close()
+		return nil, nil, fmt.Errorf("ant: diskqueue open - %w", err)
+	}
+	seg.log = log
+
+	var offset int64
+	r = bufio.NewReader(log)
+	for {
+		rawurl, n, ok := decodeRecord(r)
+		if !ok {
+			break
+		}
+
+		seq := base + uint64(seg.count)
+		offset += n
+		seg.count++
+
+		if acked[seq] {
+			seg.acked++
+			continue
+		}
+
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			seg.close()
+			return nil, nil, fmt.Errorf("ant: diskqueue parse url %q - %w", rawurl, err)
+		}
+
+		entries = append(entries, entry{seq: seq, url: u})
+	}
+
+	// A crash may leave a partially written record at the end
+	// of the segment, truncate it so that appends are readable.
+	if err := log.Truncate(offset); err != nil {
+		seg.close()
+		return nil, nil, fmt.Errorf("ant: diskqueue truncate - %w", err)
+	}
+
+	if _, err := log.Seek(offset, io.SeekStart); err != nil {
+		seg.close()
+		return nil, nil, fmt.Errorf("ant: diskqueue seek - %w", err)
+	}
+
+	return seg, entries, nil
+}
+
+// Enqueue implementation.
+func (dq *diskqueue) Enqueue(ctx context.Context, urls URLs) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	dq.cond.L.Lock()
+	defer dq.cond.L.Unlock()
+
+	if dq.stopped {
+		return io.EOF
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	entries, err := dq.append(urls)
+	if err != nil {
+		return err
+	}
+
+	dq.pending = append(dq.pending, entries...)
+	dq.wg.Add(len(entries))
+	dq.cond.Broadcast()
+
+	return nil
+}
+
+// Append appends the given URLs to the active segments.
+func (dq *diskqueue) append(urls URLs) ([]entry, error) {
+	var entries = make([]entry, 0, len(urls))
+	var dirty = make(map[*segment]struct{})
+
+	for _, u := range urls {
+		if dq.active == nil || dq.active.full() {
+			if err := dq.roll(); err != nil {
+				return nil, err
+			}
+		}
+
+		var seg = dq.active
+		var seq = seg.base + uint64(seg.count)
+
+		if _, err := seg.log.Write(encodeRecord(u.String())); err != nil {
+			return nil, fmt.Errorf("ant: diskqueue write - %w", err)
+		}
+
+		seg.count++
+		dirty[seg] = struct{}{}
+		entries = append(entries, entry{seq: seq, url: u})
+		dq.seq = seq + 1
+	}
+
+	for seg := range dirty {
+		if err := seg.log.Sync(); err != nil {
+			return nil, fmt.Errorf("ant: diskqueue fsync - %w", err)
+		}
+	}
+
+	return entries, nil
+}
+
+// Roll creates a new active segment.
+func (dq *diskqueue) roll() error {
+	var base = dq.seq
+
+	log, err := os.OpenFile(dq.filename(base, "seg"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("ant: diskqueue create - %w", err)
+	}
+
+	ack, err := os.OpenFile(dq.filename(base, "ack"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		log.Close()
+		return fmt.Errorf("ant: diskqueue create - %w", err)
+	}
+
+	dq.active = &segment{base: base, log: log, ack: ack}
+	dq.segments[base] = dq.active
+	return nil
+}
+
+// Dequeue implementation.
+func (dq *diskqueue) Dequeue(ctx context.Context) (*URL, error) {
+	dq.cond.L.Lock()
+	defer dq.cond.L.Unlock()
+
+	for len(dq.pending) == 0 {
+		if dq.stopped {
+			return nil, io.EOF
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dq.cond.Wait()
+	}
+
+	e := dq.pending[0]
+	dq.pending = dq.pending[1:]
+
+	key := e.url.String()
+	dq.inflight[key] = append(dq.inflight[key], e.seq)
+
+	return e.url, nil
+}
+
+// Held returns the amount of pending entries of each URL.
+func (dq *diskqueue) held() map[string]int {
+	dq.cond.L.Lock()
+	defer dq.cond.L.Unlock()
+
+	var ret = make(map[string]int, len(dq.pending))
+
+	for _, e := range dq.pending {
+		ret[e.url.String()]++
+	}
+
+	return ret
+}
+
+// Done implementation.
+//
+// When the given context is canceled the URL is not acknowledged
+// and it will be delivered again when the queue is re-opened.
+func (dq *diskqueue) Done(ctx context.Context, u *URL) error {
+	dq.cond.L.Lock()
+	defer dq.cond.L.Unlock()
+
+	var key = u.String()
+	var seqs = dq.inflight[key]
+
+	if len(seqs) == 0 {
+		return nil
+	}
+
+	seq := seqs[0]
+	if len(seqs) == 1 {
+		delete(dq.inflight, key)
+	} else {
+		dq.inflight[key] = seqs[1:]
+	}
+
+	defer dq.wg.Done()
+	defer dq.release()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return dq.ack(seq)
+}
+
+// Ack acknowledges the given sequence.
+func (dq *diskqueue) ack(seq uint64) error {
+	var seg *segment
+
+	for _, s := range dq.segments {
+		if seq >= s.base && seq < s.base+uint64(s.count) {
+			seg = s
+			break
+		}
+	}
+
+	if seg == nil {
+		return nil
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seq)
+
+	if _, err := seg.ack.Write(buf[:]); err != nil {
+		return fmt.Errorf("ant: diskqueue ack - %w", err)
+	}
+
+	if seg.acked++; seg.full() && seg.acked >= seg.count {
+		return dq.remove(seg)
+	}
+
+	return nil
+}
+
+// Remove removes the given segment.
+func (dq *diskqueue) remove(seg *segment) error {
+	seg.close()
+	delete(dq.segments, seg.base)
+
+	if dq.active == seg {
+		dq.active = nil
+	}
+
+	for _, ext := range [...]string{"seg", "ack"} {
+		if err := os.Remove(dq.filename(seg.base, ext)); err != nil {
+			return fmt.Errorf("ant: diskqueue compact - %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Wait implementation.
+func (dq *diskqueue) Wait() {
+	dq.wg.Wait()
+}
+
+// Close implementation.
+//
+// The method discards all queued URLs from memory, they
+// remain on disk and are delivered again when the queue
+// is re-opened.
+func (dq *diskqueue) Close(_ context.Context) error {
+	dq.cond.L.Lock()
+	defer dq.cond.L.Unlock()
+
+	for range dq.pending {
+		dq.wg.Done()
+	}
+
+	dq.stopped = true
+	dq.pending = dq.pending[:0]
+	dq.cond.Broadcast()
+	dq.release()
+	return nil
+}
+
+// Release closes all open files once the queue is
+// stopped and there are no more in-flight URLs.
+func (dq *diskqueue) release() {
+	if dq.closed || !dq.stopped || len(dq.inflight) > 0 {
+		return
+	}
+
+	for _, seg := range dq.segments {
+		seg.close()
+	}
+
+	dq.closed = true
+}
+
+// Filename returns the filename of a segment file.
+func (dq *diskqueue) filename(base uint64, ext string) string {
+	return filepath.Join(dq.path, fmt.Sprintf("%020d.%s", base, ext))
+}
+
+// Close closes the segment's files.
+func (s *segment) close() {
+	if s.log != nil {
+		s.log.Close()
+		s.log = nil
+	}
+	if s.ack != nil {
+		s.ack.Close()
+		s.ack = nil
+	}
+}
+
+// EncodeRecord encodes a record.
+//
+// A record is a 4 byte length, followed by a 4 byte
+// crc32 checksum and the URL.
+func encodeRecord(rawurl string) []byte {
+	var buf = make([]byte, 8+len(rawurl))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(rawurl)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE([]byte(rawurl)))
+	copy(buf[8:], rawurl)
+	return buf
+}
+
+// DecodeRecord reads a single record from r.
+//
+// The method returns false if the record is incomplete
+// or its checksum does not match.
+func decodeRecord(r io.Reader) (string, int64, bool) {
+	var hdr [8]byte
+
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return "", 0, false
+	}
+
+	var size = binary.BigEndian.Uint32(hdr[0:4])
+	var sum = binary.BigEndian.Uint32(hdr[4:8])
+	var buf = make([]byte, size)
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", 0, false
+	}
+
+	if crc32.ChecksumIEEE(buf) != sum {
+		return "", 0, false
+	}
+
+	return string(buf), int64(8 + size), true
+}
@@ -0,0 +1,174 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskqueue(t *testing.T) {
+	t.Run("open missing", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := DiskQueue("/tmp/ant-missing-queue")
+
+		assert.Error(err)
+		assert.Contains(err.Error(), `no such file or directory`)
+	})
+
+	t.Run("redeliver after restart", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var dir = t.TempDir()
+		var urls = parseURLs(t, "https://a", "https://b", "https://c")
+
+		q, err := DiskQueue(dir)
+		assert.NoError(err)
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		a, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.NoError(q.Done(ctx, a))
+
+		b, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://b", b.String())
+		assert.NoError(q.Close(ctx))
+
+		q, err = DiskQueue(dir)
+		assert.NoError(err)
+
+		b, err = q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://b", b.String())
+
+		c, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://c", c.String())
+		assert.NoError(q.Close(ctx))
+	})
+
+	t.Run("done with canceled context", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var dir = t.TempDir()
+
+		q, err := DiskQueue(dir)
+		assert.NoError(err)
+		assert.NoError(q.Enqueue(ctx, parseURLs(t, "https://a")))
+
+		a, err := q.Dequeue(ctx)
+		assert.NoError(err)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		assert.NoError(q.Done(canceled, a))
+		q.Wait()
+		assert.NoError(q.Close(ctx))
+
+		q, err = DiskQueue(dir)
+		assert.NoError(err)
+
+		a, err = q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://a", a.String())
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+
+		q, err := DiskQueue(t.TempDir())
+		assert.NoError(err)
+		assert.NoError(q.Enqueue(ctx, parseURLs(t, "https://a")))
+
+		go func() {
+			u, _ := q.Dequeue(ctx)
+			q.Done(ctx, u)
+		}()
+
+		q.Wait()
+		assert.NoError(q.Close(ctx))
+
+		_, err = q.Dequeue(ctx)
+		assert.Equal(io.EOF, err)
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var dir = t.TempDir()
+
+		defer func(n int) { segmentSize = n }(segmentSize)
+		segmentSize = 2
+
+		q, err := DiskQueue(dir)
+		assert.NoError(err)
+
+		urls := parseURLs(t, "https://a", "https://b", "https://c", "https://d", "https://e")
+		assert.NoError(q.Enqueue(ctx, urls))
+		assert.Equal(6, countFiles(t, dir))
+
+		for range urls[:4] {
+			u, err := q.Dequeue(ctx)
+			assert.NoError(err)
+			assert.NoError(q.Done(ctx, u))
+		}
+
+		assert.Equal(2, countFiles(t, dir))
+		assert.NoError(q.Close(ctx))
+
+		q, err = DiskQueue(dir)
+		assert.NoError(err)
+
+		e, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://e", e.String())
+	})
+
+	t.Run("partial record", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var dir = t.TempDir()
+
+		q, err := DiskQueue(dir)
+		assert.NoError(err)
+		assert.NoError(q.Enqueue(ctx, parseURLs(t, "https://a")))
+		assert.NoError(q.Close(ctx))
+
+		name := filepath.Join(dir, "00000000000000000000.seg")
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+		assert.NoError(err)
+		_, err = f.Write(encodeRecord("https://b")[:10])
+		assert.NoError(err)
+		assert.NoError(f.Close())
+
+		q, err = DiskQueue(dir)
+		assert.NoError(err)
+		assert.NoError(q.Enqueue(ctx, parseURLs(t, "https://c")))
+
+		a, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://a", a.String())
+
+		c, err := q.Dequeue(ctx)
+		assert.NoError(err)
+		assert.Equal("https://c", c.String())
+	})
+}
+
+func countFiles(t testing.TB, dir string) int {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %s", err)
+	}
+
+	return len(entries)
+}
@@ -5,12 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/yields/ant/internal/normalize"
 	"github.com/yields/ant/internal/robots"
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/sync/semaphore"
 )
 
 // EngineConfig configures the engine.
@@ -27,7 +30,9 @@ type EngineConfig struct {
 
 	// Fetcher is the page fetcher to use.
 	//
-	// If nil, the default HTTP fetcher is used.
+	// If nil, the default HTTP fetcher is used, it shares
+	// a `Throttle` between all workers, cookies are not kept
+	// unless a fetcher with `Sessions` is configured.
 	Fetcher *Fetcher
 
 	// Queue is the URL queue to use.
@@ -65,6 +70,9 @@ type EngineConfig struct {
 	// Every worker the engine start consumes URLs from the queue
 	// and starts a goroutine for each URL.
 	//
+	// The amount can be changed while the engine
+	// is running with `Engine.SetWorkers()`.
+	//
 	// If <= 0, defaults to 1.
 	Workers int
 
@@ -72,10 +80,132 @@ type EngineConfig struct {
 	// at any given time.
 	//
 	// The engine uses a global semaphore to limit the amount
-	// of goroutines started by the workers.
+	// of goroutines started by the workers, the limit can be
+	// changed while the engine is running with `Engine.SetConcurrency()`.
 	//
 	// If <= 0, there's no limit.
 	Concurrency int
+
+	// Autoscaler adjusts the concurrency while the engine is running.
+	//
+	// When set, the concurrency starts at `Concurrency` clamped
+	// to the autoscaler's bounds.
+	//
+	// If nil, the concurrency is not adjusted.
+	Autoscaler *Autoscaler
+
+	// MaxDepth is the maximum depth of URLs to follow.
+	//
+	// The initial URLs have a depth of 0, URLs found on them
+	// have a depth of 1 and so on, URLs that exceed the depth
+	// are discarded before they are de-duplicated.
+	//
+	// URLs that are delivered by a durable queue after a restart
+	// without a checkpoint are treated as initial URLs.
+	//
+	// If <= 0, there's no limit.
+	MaxDepth int
+
+	// MaxPages is the maximum amount of pages to visit.
+	//
+	// URLs disallowed by robots.txt are not counted, the engine
+	// halts when a dequeued URL would exceed the budget, it waits
+	// for all in-flight pages and returns a `*BudgetError`.
+	//
+	// If <= 0, there's no limit.
+	MaxPages int
+
+	// MaxDuration is the maximum duration of the crawl.
+	//
+	// When the duration elapses the engine stops dequeuing URLs
+	// waits for all in-flight pages and returns a `*BudgetError`.
+	//
+	// If <= 0, there's no limit.
+	MaxDuration time.Duration
+
+	// MaxBytes is the maximum amount of body bytes to download.
+	//
+	// The budget is checked after each page is scraped, when it runs
+	// out the engine stops dequeuing URLs waits for all in-flight pages
+	// and returns a `*BudgetError`.
+	//
+	// If <= 0, there's no limit.
+	MaxBytes int64
+
+	// MaxHostPages is the maximum amount of pages to visit per host.
+	//
+	// Unlike the other budgets, it does not stop the engine, URLs
+	// of a host that reached its cap are discarded before they are
+	// queued.
+	//
+	// If <= 0, there's no limit.
+	MaxHostPages int
+
+	// MaxAttempts is the maximum attempts to make when a scraper
+	// or a deduper returns a temporary error.
+	//
+	// An error is temporary if it implements a `Temporary() bool`
+	// method that returns true, when a scraper fails temporarily the
+	// page is fetched and scraped again.
+	//
+	// When <= 0, it defaults to 5.
+	MaxAttempts int
+
+	// MinBackoff to use when the engine retries.
+	//
+	// Must be less than MaxBackoff, otherwise
+	// NewEngine returns an error.
+	//
+	// Defaults to `50ms`.
+	MinBackoff time.Duration
+
+	// MaxBackoff to use when the engine retries.
+	//
+	// Must be greater than MinBackoff, otherwise
+	// NewEngine returns an error.
+	//
+	// Defaults to `1s`.
+	MaxBackoff time.Duration
+
+	// OnError is the error policy to use.
+	//
+	// The policy is called when a URL fails and decides if the URL
+	// is skipped, retried later or if the crawl is aborted, see
+	// `SkipOnError()`, `RetryOnError()` and `StopAfterErrors()`.
+	//
+	// If nil, `AbortOnError()` is used.
+	OnError ErrorPolicy
+
+	// Logger is the logger to use.
+	//
+	// The engine logs pages at debug level, retries and skipped
+	// URLs at warn level, when the fetcher is nil the default
+	// fetcher and robots.txt checks use the logger as well.
+	//
+	// If nil, the engine does not log.
+	Logger *slog.Logger
+
+	// Hooks are called on engine lifecycle events.
+	//
+	// They can be used to observe the crawl, for example
+	// to report progress or collect metrics.
+	Hooks Hooks
+
+	// Resume is a checkpoint to resume the crawl from.
+	//
+	// When set, the engine restores the deduper's state and
+	// queues all URLs from the checkpoint when `Run()` is called,
+	// see `Engine.Checkpoint()`, URLs that a `DiskQueue()` still
+	// holds are not queued again.
+	//
+	// If nil, the crawl starts from scratch.
+	Resume io.Reader
+
+	// Seeder returns additional requests to queue when
+	// `Run()` is called, e.g. the entries of `Sitemaps`.
+	//
+	// If nil, the crawl starts from the start URLs only.
+	Seeder Seeder
 }
 
 // Engine implements web crawler engine.
@@ -88,8 +218,33 @@ type Engine struct {
 	limiter  Limiter
 	robots   *robots.Cache
 	impolite bool
-	workers  int
-	sema     *semaphore.Weighted
+	delayed  bool
+	pool     *pool
+	scaler   *scaler
+	maxDepth int
+	maxTime  time.Duration
+	sema     *sema
+	pending  *tracker
+	resume   []*tracked
+	seeder   Seeder
+	budgets  *budgets
+	onError  ErrorPolicy
+	hooks    Hooks
+	logger   *slog.Logger
+	attempts int
+	minWait  time.Duration
+	maxWait  time.Duration
+	visited  atomic.Int64
+	failmu   sync.Mutex
+	failures []Failure
+	gate     gate
+	stop     chan struct{}
+	running  atomic.Bool
+	finished chan struct{}
+	finish   sync.Once
+	haltmu   sync.Mutex
+	halts    bool
+	haltErr  error
 }
 
 // NewEngine returns a new engine.
@@ -102,8 +257,15 @@ func NewEngine(c EngineConfig) (*Engine, error) {
 		c.Deduper = DedupeMap()
 	}
 
+	if c.Logger == nil {
+		c.Logger = discard
+	}
+
 	if c.Fetcher == nil {
-		c.Fetcher = &Fetcher{}
+		c.Fetcher = &Fetcher{
+			Logger:   c.Logger,
+			Throttle: &Throttle{},
+		}
 	}
 
 	if c.Workers <= 0 {
@@ -114,9 +276,60 @@ func NewEngine(c EngineConfig) (*Engine, error) {
 		c.Queue = MemoryQueue(c.Workers)
 	}
 
-	var sema *semaphore.Weighted
-	if n := int64(c.Concurrency); n > 0 {
-		sema = semaphore.NewWeighted(n)
+	if c.OnError == nil {
+		c.OnError = AbortOnError()
+	}
+
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = minBackoff
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = maxBackoff
+	}
+
+	if c.MinBackoff >= c.MaxBackoff {
+		return nil, errors.New("ant: min backoff must be less than max backoff")
+	}
+
+	var scaler *scaler
+	if c.Autoscaler != nil {
+		s, err := newScaler(*c.Autoscaler)
+		if err != nil {
+			return nil, err
+		}
+		scaler = s
+		c.Concurrency = min(max(c.Concurrency, s.Min), s.Max)
+	}
+
+	var resume []*tracked
+	if c.Resume != nil {
+		urls, err := restore(c.Resume, c.Deduper)
+		if err != nil {
+			return nil, err
+		}
+		resume = urls
+	}
+
+	var rcache = robots.NewCache(DefaultClient, 1000)
+	rcache.Logger = c.Logger
+
+	// The host queue applies the crawl delays, so
+	// that workers do not block on robots.txt.
+	var delayed bool
+	if hq, ok := c.Queue.(*hostqueue); ok && !c.Impolite {
+		hq.crawl = func(ctx context.Context, u *URL) time.Duration {
+			d, _ := rcache.Delay(ctx, robots.Request{
+				URL:       u,
+				UserAgent: UserAgent.String(),
+			})
+			return d
+		}
+		delayed = true
 	}
 
 	return &Engine{
@@ -126,10 +339,31 @@ func NewEngine(c EngineConfig) (*Engine, error) {
 		queue:    c.Queue,
 		matcher:  c.Matcher,
 		limiter:  c.Limiter,
-		robots:   robots.NewCache(DefaultClient, 1000),
+		robots:   rcache,
 		impolite: c.Impolite,
-		workers:  c.Workers,
-		sema:     sema,
+		delayed:  delayed,
+		pool:     &pool{target: c.Workers},
+		scaler:   scaler,
+		maxDepth: c.MaxDepth,
+		maxTime:  c.MaxDuration,
+		sema:     newSema(c.Concurrency),
+		pending:  newTracker(),
+		resume:   resume,
+		seeder:   c.Seeder,
+		onError:  c.OnError,
+		hooks:    c.Hooks,
+		logger:   c.Logger,
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+		attempts: c.MaxAttempts,
+		minWait:  c.MinBackoff,
+		maxWait:  c.MaxBackoff,
+		budgets: &budgets{
+			maxPages:     int64(c.MaxPages),
+			maxBytes:     c.MaxBytes,
+			maxHostPages: c.MaxHostPages,
+			hosts:        make(map[string]int),
+		},
 	}, nil
 }
 
@@ -137,21 +371,90 @@ func NewEngine(c EngineConfig) (*Engine, error) {
 func (eng *Engine) Run(ctx context.Context, urls ...string) error {
 	var eg, subctx = errgroup.WithContext(ctx)
 
+	eng.running.Store(true)
+	defer eng.running.Store(false)
+
+	defer eng.finish.Do(func() {
+		close(eng.finished)
+	})
+
+	// Report queued URLs that were not visited.
+	defer func() {
+		eng.hooks.drop(ctx, eng.pending.queued())
+	}()
+
+	// Enqueue URLs from the checkpoint, they were already
+	// de-duplicated, URLs that a durable queue delivers
+	// again are tracked without queueing them twice.
+	if resume := eng.resume; len(resume) > 0 {
+		var reqs = make(Requests, 0, len(resume))
+		var vs = make([]*tracked, 0, len(resume))
+		var held map[string]int
+		var kept []*tracked
+
+		if dq, ok := eng.queue.(*diskqueue); ok {
+			held = dq.held()
+		}
+
+		eng.resume = nil
+		for _, v := range resume {
+			t := eng.pending.add(v.url, v.meta)
+			if k := v.url.String(); held[k] > 0 {
+				held[k]--
+				kept = append(kept, t)
+				continue
+			}
+			vs = append(vs, t)
+			reqs = append(reqs, v.request(v.url))
+		}
+
+		eng.pending.queue(kept)
+
+		if err := eng.push(ctx, vs, reqs); err != nil {
+			return fmt.Errorf("ant: enqueue - %w", err)
+		}
+	}
+
 	// Enqueue initial URLs.
 	if err := eng.Enqueue(ctx, urls...); err != nil {
 		return fmt.Errorf("ant: enqueue - %w", err)
 	}
 
-	// Spawn workers.
-	for i := 0; i < eng.workers; i++ {
-		eg.Go(func() error {
-			defer eng.queue.Close(ctx)
-			return eng.run(subctx)
+	// Enqueue URLs from the seeder.
+	if err := eng.seed(ctx, urls); err != nil {
+		return err
+	}
+
+	// Stop the crawl when the duration elapses.
+	if d := eng.maxTime; d > 0 {
+		t := time.AfterFunc(d, func() {
+			eng.halt(&BudgetError{Budget: BudgetDuration})
 		})
+		defer t.Stop()
+	}
+
+	// Adjust the concurrency.
+	if eng.scaler != nil {
+		scalectx, cancel := context.WithCancel(subctx)
+		defer cancel()
+		go eng.scaler.run(scalectx, eng)
 	}
 
-	// Wait until all URLs are handled.
+	// Spawn workers, a retired worker exits
+	// without closing the queue.
+	eng.pool.start(subctx, eg, func(subctx context.Context) error {
+		if err := eng.run(subctx); !errors.Is(err, errRetired) {
+			eng.queue.Close(ctx)
+			return err
+		}
+		return nil
+	})
+
+	// Wait until all URLs are handled and
+	// the engine is not paused.
 	eng.queue.Wait()
+	eng.gate.wait(ctx, eng.stop)
+	eng.pool.stop()
 	if err := eng.queue.Close(ctx); err != nil {
 		return err
 	}
@@ -161,9 +464,65 @@ func (eng *Engine) Run(ctx context.Context, urls ...string) error {
 		return fmt.Errorf("ant: run - %w", err)
 	}
 
+	return eng.halted()
+}
+
+// Seed enqueues the requests of the seeder for the start urls.
+func (eng *Engine) seed(ctx context.Context, rawurls []string) error {
+	if eng.seeder == nil {
+		return nil
+	}
+
+	var urls = make([]*URL, 0, len(rawurls))
+
+	for _, rawurl := range rawurls {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return fmt.Errorf("ant: parse url %q - %w", rawurl, err)
+		}
+		urls = append(urls, u)
+	}
+
+	reqs, err := eng.seeder.Seed(ctx, urls)
+	if err != nil {
+		return fmt.Errorf("ant: seed - %w", err)
+	}
+
+	if err := eng.EnqueueRequests(ctx, reqs...); err != nil {
+		return fmt.Errorf("ant: enqueue - %w", err)
+	}
+
 	return nil
 }
 
+// Halt stops the engine with err.
+//
+// The method closes the queue, the workers stop dequeuing
+// URLs and `Run()` returns once all in-flight URLs are handled.
+func (eng *Engine) halt(err error) {
+	eng.haltmu.Lock()
+	if eng.halts {
+		eng.haltmu.Unlock()
+		return
+	}
+	eng.halts = true
+	eng.haltErr = err
+	eng.haltmu.Unlock()
+
+	close(eng.stop)
+
+	eng.logger.Info("ant: halt", "error", err)
+
+	eng.queue.Close(context.Background())
+}
+
+// Halted returns the error the engine was halted with.
+func (eng *Engine) halted() error {
+	eng.haltmu.Lock()
+	defer eng.haltmu.Unlock()
+	return eng.haltErr
+}
+
 // Enqueue enqueues the given set of URLs.
 //
 // The method blocks until all URLs are queued
@@ -172,41 +531,138 @@ func (eng *Engine) Run(ctx context.Context, urls ...string) error {
 // The method will also de-duplicate the URLs, ensuring
 // that URLs will not be visited more than once.
 func (eng *Engine) Enqueue(ctx context.Context, rawurls ...string) error {
-	var batch = make(URLs, 0, len(rawurls))
+	var batch = make(Requests, 0, len(rawurls))
 
 	for _, rawurl := range rawurls {
 		u, err := url.Parse(rawurl)
 		if err != nil {
 			return fmt.Errorf("ant: parse url %q - %w", rawurl, err)
 		}
+		batch = append(batch, NewRequest(u))
+	}
+
+	return eng.EnqueueRequests(ctx, batch...)
+}
+
+// EnqueueRequests enqueues the given set of requests.
+//
+// The method behaves like `Enqueue()`, the requests
+// are de-duplicated by their URL.
+func (eng *Engine) EnqueueRequests(ctx context.Context, reqs ...*Request) error {
+	for _, r := range reqs {
+		if r.URL == nil {
+			return fmt.Errorf("ant: cannot enqueue a request without a URL")
+		}
 
-		switch u.Scheme {
+		switch r.URL.Scheme {
 		case "https", "http":
 		default:
-			return fmt.Errorf("ant: cannot enqueue invalid URL %q", u)
+			return fmt.Errorf("ant: cannot enqueue invalid URL %q", r.URL)
 		}
-
-		batch = append(batch, u)
 	}
 
-	return eng.enqueue(ctx, batch)
+	return eng.enqueue(ctx, reqs, meta{})
 }
 
-// Enqueue enqueues the given parsed urls.
-func (eng *Engine) enqueue(ctx context.Context, batch URLs) error {
-	for j := range batch {
-		batch[j] = normalize.URL(batch[j])
+// Enqueue enqueues the given requests with metadata m.
+//
+// The requests are copied, requests without
+// a depth are queued with the depth of m.
+func (eng *Engine) enqueue(ctx context.Context, reqs Requests, m meta) error {
+	var batch = make(Requests, 0, len(reqs))
+
+	// Every request gets its own URL, which
+	// identifies its entry once it is dequeued.
+	for _, r := range reqs {
+		req := *r
+		u := *r.URL
+		req.URL = normalize.URL(&u)
+		if req.Depth <= 0 {
+			req.Depth = m.depth
+		}
+		batch = append(batch, &req)
+	}
+
+	if eng.maxDepth > 0 {
+		var kept = make(Requests, 0, len(batch))
+		for _, r := range batch {
+			if r.Depth <= eng.maxDepth {
+				kept = append(kept, r)
+			}
+		}
+		eng.hooks.discard(ctx, batch.URLs(), kept.URLs(), DiscardDepth)
+		batch = kept
 	}
 
-	next, err := eng.dedupe(ctx, eng.matches(batch))
+	var urls = batch.URLs()
+
+	matched := eng.matches(urls)
+	eng.hooks.discard(ctx, urls, matched, DiscardMatcher)
+	batch = batch.only(matched)
+
+	urls = batch.URLs()
+	eng.hooks.dedupe(ctx, urls)
+
+	deduped, err := eng.dedupe(ctx, batch.keys())
 	if err != nil {
 		return err
 	}
+	batch = batch.dedupe(deduped)
+	eng.hooks.discard(ctx, urls, batch.URLs(), DiscardDuplicate)
+
+	urls = batch.URLs()
+	next := eng.budgets.filter(urls)
+	eng.hooks.discard(ctx, urls, next, DiscardHostPages)
+
+	batch = batch.only(next)
+	var vs = make([]*tracked, 0, len(batch))
+	for _, r := range batch {
+		vs = append(vs, eng.pending.add(r.URL, meta{
+			depth:    r.Depth,
+			referrer: m.referrer,
+			req:      r,
+		}))
+	}
+
+	// The queue is closed when the engine is halted, the URLs
+	// remain pending and can be resumed from a checkpoint.
+	if err := eng.push(ctx, vs, batch); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
 
-	if err := eng.queue.Enqueue(ctx, next); err != nil {
+	return nil
+}
+
+// Push queues the requests reqs of the tracked entries vs.
+//
+// On success the entries are marked as queued and the OnEnqueue
+// hook is called, URLs that a bounded queue evicted are untracked
+// and passed to the OnDiscard hook with `DiscardEvicted`.
+func (eng *Engine) push(ctx context.Context, vs []*tracked, reqs Requests) error {
+	var qerr *QueueFullError
+	var urls = make(URLs, 0, len(vs))
+
+	if err := enqueueRequests(ctx, eng.queue, reqs); err != nil && !errors.As(err, &qerr) {
 		return err
 	}
 
+	for _, v := range vs {
+		urls = append(urls, v.url)
+	}
+
+	eng.pending.queue(vs)
+	eng.hooks.enqueue(ctx, urls)
+
+	if qerr != nil {
+		for _, u := range qerr.Evicted {
+			eng.pending.evict(u)
+		}
+		eng.hooks.discard(ctx, qerr.Evicted, nil, DiscardEvicted)
+	}
+
 	return nil
 }
 
@@ -217,6 +673,17 @@ func (eng *Engine) enqueue(ctx context.Context, batch URLs) error {
 func (eng *Engine) run(ctx context.Context) error {
 	eg, subctx := errgroup.WithContext(ctx)
 	for {
+		if err := eng.gate.wait(ctx, eng.stop); err != nil {
+			return eg.Wait()
+		}
+
+		if eng.pool.retire() {
+			if err := eg.Wait(); err != nil {
+				return err
+			}
+			return errRetired
+		}
+
 		url, err := eng.queue.Dequeue(ctx)
 
 		if errors.Is(err, io.EOF) ||
@@ -227,25 +694,56 @@ func (eng *Engine) run(ctx context.Context) error {
 			return err
 		}
 
-		if eng.sema != nil {
-			if err := eng.sema.Acquire(ctx, 1); err != nil {
-				return err
-			}
+		// A page is reserved only for a dequeued URL, the
+		// URL remains pending when the budget ran out.
+		if !eng.budgets.reserve() {
+			eng.halt(&BudgetError{Budget: BudgetPages})
+			eng.done(ctx, url, nil)
+			return eg.Wait()
+		}
+
+		// The engine may be paused while the worker
+		// was blocked on the queue.
+		if err := eng.gate.wait(ctx, eng.stop); err != nil {
+			eng.budgets.release()
+			eng.done(ctx, url, nil)
+			return eg.Wait()
+		}
+
+		v := eng.pending.start(url)
+		eng.hooks.dequeue(ctx, url)
+
+		if err := eng.sema.acquire(ctx); err != nil {
+			eng.done(ctx, url, v)
+			eg.Wait()
+			return err
 		}
 
 		eg.Go(func() error {
-			if eng.sema != nil {
-				defer eng.sema.Release(1)
-			}
-			return eng.process(subctx, url)
+			defer eng.sema.release()
+			return eng.process(subctx, v)
 		})
 	}
 }
 
 // Process processes a single url.
-func (eng *Engine) process(ctx context.Context, url *URL) error {
-	defer eng.queue.Done(ctx, url)
+//
+// Any error that occurs is handled by the error policy.
+func (eng *Engine) process(ctx context.Context, v *tracked) error {
+	var url, m = v.url, v.meta
+
+	defer eng.done(ctx, url, v)
+
+	ctx = withHooks(ctx, &eng.hooks)
+
+	var err = eng.visit(ctx, url, m)
+
+	eng.hooks.done(ctx, url, err)
+	return eng.failed(ctx, url, m, err)
+}
 
+// Visit visits a single url.
+func (eng *Engine) visit(ctx context.Context, url *URL, m meta) error {
 	// Check robots.txt.
 	if !eng.impolite {
 		allowed, err := eng.robots.Allowed(ctx, robots.Request{
@@ -256,6 +754,9 @@ func (eng *Engine) process(ctx context.Context, url *URL) error {
 			return err
 		}
 		if !allowed {
+			eng.budgets.release()
+			eng.hooks.disallowed(ctx, url)
+			eng.logger.DebugContext(ctx, "ant: robots disallowed", attrs(url)...)
 			return nil
 		}
 	}
@@ -266,49 +767,182 @@ func (eng *Engine) process(ctx context.Context, url *URL) error {
 	}
 
 	// Scrape the URL.
-	urls, err := eng.scrape(ctx, url)
+	reqs, err := eng.scrape(ctx, url, m)
 	if err != nil {
 		return err
 	}
 
-	// Enqueue URLs.
-	if err := eng.enqueue(ctx, urls); err != nil {
+	// Enqueue requests.
+	next := meta{depth: m.depth + 1, referrer: url}
+	if err := eng.enqueue(ctx, reqs, next); err != nil {
 		return fmt.Errorf("ant: enqueue - %w", err)
 	}
 
 	return nil
 }
 
-// Scrape scrapes the given URL and returns the next URLs.
-func (eng *Engine) scrape(ctx context.Context, url *URL) (URLs, error) {
-	page, err := eng.fetcher.Fetch(ctx, url)
-
-	if err != nil {
-		return nil, fmt.Errorf("ant: fetch %q - %w", url, err)
+// Done marks the given URL and its tracked entry v as handled.
+//
+// When the context is canceled or v is nil the URL remains
+// pending so that it is visited again when the crawl is resumed.
+func (eng *Engine) done(ctx context.Context, url *URL, v *tracked) {
+	if v != nil && ctx.Err() == nil {
+		eng.pending.done(v)
 	}
+	eng.queue.Done(ctx, url)
+}
+
+// Scrape scrapes the given URL and returns the next requests.
+//
+// When the scraper returns a temporary error the
+// page is fetched and scraped again.
+func (eng *Engine) scrape(ctx context.Context, url *URL, m meta) (Requests, error) {
+	var req = m.request(url)
+
+	for attempt := 1; ; attempt++ {
+		var start = time.Now()
+
+		eng.hooks.fetchStart(ctx, url)
+		page, err := eng.fetcher.FetchRequest(ctx, req)
+		eng.observe(start, page, err)
+
+		var cterr *ContentTypeError
+		if errors.As(err, &cterr) {
+			eng.hooks.fetchDone(ctx, url, cterr.Status, 0, start)
+			eng.logger.DebugContext(ctx, "ant: skip content type", append(attrs(url),
+				"content_type", cterr.ContentType,
+			)...)
+			eng.fail(url, err)
+			return nil, nil
+		}
+
+		if err != nil {
+			var ferr *FetchError
+			var status int
+			if errors.As(err, &ferr) {
+				status = ferr.Status
+			}
+			eng.hooks.fetchDone(ctx, url, status, 0, start)
+			return nil, fmt.Errorf("ant: fetch %q - %w", url, err)
+		}
+
+		if page == nil {
+			eng.hooks.fetchDone(ctx, url, 404, 0, start)
+			return nil, nil
+		}
+
+		body := &counter{rc: page.body}
+		page.body = body
+		page.Depth = m.depth
+		page.Referrer = m.referrer
+		page.Meta = req.Meta
+
+		// Buffer the body before it is scraped, a body that exceeds
+		// the fetcher's limits or stalls is failed without scraping.
+		page.load()
+		eng.hooks.fetchDone(ctx, url, page.StatusCode, body.n, start)
+
+		if err := body.guarded(); err != nil {
+			page.close()
+			if !eng.budgets.download(body.n) {
+				eng.halt(&BudgetError{Budget: BudgetBytes})
+			}
+			return nil, fmt.Errorf("ant: fetch %q - %w", url, err)
+		}
+
+		eng.hooks.page(ctx, page)
+		reqs, err := scrapeRequests(ctx, eng.scraper, page)
+
+		// Bodies that exceed the buffer limit are streamed, the
+		// remaining body is drained after the scrape, a body that
+		// exceeds the size limit or stalls while it is drained
+		// fails the page even if the scraper did not read it.
+		page.close()
+		berr := body.guarded()
+		eng.visited.Add(1)
+		eng.logger.DebugContext(ctx, "ant: scrape", append(attrs(url),
+			"status", page.StatusCode,
+			"attempt", attempt,
+			"duration", time.Since(start),
+		)...)
+
+		if berr != nil {
+			err = fmt.Errorf("ant: fetch %q - %w", url, berr)
+			reqs = nil
+		}
+
+		eng.hooks.scrape(ctx, url, reqs.URLs(), err)
+
+		if !eng.budgets.download(body.n) {
+			eng.halt(&BudgetError{Budget: BudgetBytes})
+		}
+
+		if berr != nil {
+			return nil, err
+		}
 
-	if page == nil {
-		return nil, nil
+		if err == nil {
+			return reqs, nil
+		}
+
+		if err := eng.retry(ctx, url, attempt, err); err != nil {
+			return nil, fmt.Errorf("ant: scrape %q - %w", url, err)
+		}
 	}
+}
 
-	defer page.close()
+// Observe reports a fetch to the autoscaler.
+func (eng *Engine) observe(start time.Time, page *Page, err error) {
+	if eng.scaler == nil || errors.Is(err, context.Canceled) {
+		return
+	}
 
-	urls, err := eng.scraper.Scrape(ctx, page)
-	if err != nil {
-		return nil, fmt.Errorf("ant: scrape %q - %w", url, err)
+	var failed = err != nil
+	if page != nil {
+		failed = page.StatusCode == 429 || page.StatusCode >= 500
 	}
 
-	return urls, nil
+	eng.scaler.observe(time.Since(start), failed)
 }
 
 // Dedupe de-duplicates the given slice of URLs.
+//
+// When the deduper returns a temporary error
+// the URLs are de-duplicated again.
 func (eng *Engine) dedupe(ctx context.Context, urls URLs) (URLs, error) {
-	deduped, err := eng.deduper.Dedupe(ctx, urls)
-	if err != nil {
-		return nil, fmt.Errorf("ant: dedupe - %w", err)
+	for attempt := 1; ; attempt++ {
+		deduped, err := eng.deduper.Dedupe(ctx, urls)
+
+		if err == nil {
+			return deduped, nil
+		}
+
+		if err := eng.retry(ctx, nil, attempt, err); err != nil {
+			return nil, fmt.Errorf("ant: dedupe - %w", err)
+		}
+	}
+}
+
+// Retry decides if a failed attempt should be made again.
+//
+// If err is temporary and there are attempts left the method
+// backs off and returns nil, otherwise it returns the error.
+func (eng *Engine) retry(ctx context.Context, url *URL, attempt int, err error) error {
+	if !wrapsTemporary(err) {
+		return err
+	}
+
+	if attempt >= eng.attempts {
+		return fmt.Errorf("ant: max attempts of %d reached - %w", eng.attempts, err)
 	}
 
-	return deduped, nil
+	eng.hooks.retry(ctx, url, attempt, err)
+	eng.logger.WarnContext(ctx, "ant: retry", append(attrs(url),
+		"attempt", attempt,
+		"error", err,
+	)...)
+
+	return backoff(ctx, attempt, eng.minWait, eng.maxWait)
 }
 
 // Limit runs all configured limiters.
@@ -319,7 +953,7 @@ func (eng *Engine) limit(ctx context.Context, url *URL) error {
 		}
 	}
 
-	if eng.impolite {
+	if eng.impolite || eng.delayed {
 		return nil
 	}
 
@@ -347,3 +981,13 @@ func (eng *Engine) matches(urls URLs) URLs {
 	}
 	return urls
 }
+
+// Attrs returns the log attributes of url.
+//
+// If url is nil, the method returns no attributes.
+func attrs(url *URL) []any {
+	if url == nil {
+		return nil
+	}
+	return []any{"url", url.String(), "host", url.Host}
+}
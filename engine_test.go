@@ -1,16 +1,21 @@
 package ant
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"path"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +69,273 @@ func TestEngine(t *testing.T) {
 		assert.Equal(expect, visitor.paths)
 	})
 
+	t.Run("run with max depth", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.maxDepth = 1
+		err := eng.Run(ctx, srv.URL)
+
+		assert.NoError(err)
+
+		sort.Strings(visitor.paths)
+		expect := []string{
+			"/",
+			"/about.html",
+			"/products.html",
+		}
+
+		assert.Equal(expect, visitor.paths)
+	})
+
+	t.Run("run skips content types", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				w.Header().Set("Content-Type", "text/html")
+				io.WriteString(w, `<a href="/doc.pdf">doc</a><a href="/a">a</a>`)
+			case "/doc.pdf":
+				w.Header().Set("Content-Type", "application/pdf")
+			default:
+				w.Header().Set("Content-Type", "text/html")
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: visitor,
+			Fetcher: &Fetcher{AllowedTypes: []string{"text/html"}},
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+
+		sort.Strings(visitor.paths)
+		assert.Equal([]string{"/", "/a"}, visitor.paths)
+
+		failures := eng.Summary().Failures
+		assert.Len(failures, 1)
+		assert.True(strings.HasSuffix(failures[0].URL.Path, "doc.pdf"))
+		assert.IsType(&ContentTypeError{}, failures[0].Err)
+	})
+
+	t.Run("run with host queue crawl delay", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var visits []string
+		var times = make(map[string]time.Time)
+
+		pages := func(robots string) *httptest.Server {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/robots.txt":
+					io.WriteString(w, robots)
+				case "/":
+					w.Header().Set("Content-Type", "text/html")
+					io.WriteString(w, `<a href="/a">a</a><a href="/b">b</a>`)
+				default:
+					w.Header().Set("Content-Type", "text/html")
+				}
+			}))
+			t.Cleanup(srv.Close)
+			return srv
+		}
+
+		slow := pages("User-agent: *\nCrawl-delay: 0.2\n")
+		fast := pages("")
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				visits = append(visits, p.URL.String())
+				times[p.URL.String()] = time.Now()
+				return p.URLs(), nil
+			}),
+			Queue:       HostQueue(0),
+			Workers:     1,
+			Concurrency: 1,
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, slow.URL, fast.URL))
+
+		// The single slot visits the other host while
+		// the crawl delay of the slow host has not passed.
+		assert.Len(visits, 6)
+		assert.ElementsMatch([]string{slow.URL + "/a", slow.URL + "/b"}, visits[4:])
+
+		elapsed := times[slow.URL+"/a"].Sub(times[slow.URL])
+		assert.True(elapsed >= 150*time.Millisecond, "elapsed %s", elapsed)
+	})
+
+	t.Run("run sets depth and referrer", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var pages sync.Map
+
+		eng := setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			pages.Store(p.URL.Path, meta{depth: p.Depth, referrer: p.Referrer})
+			return p.URLs(), nil
+		}))
+
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		v, ok := pages.Load("/")
+		assert.True(ok)
+		assert.Equal(0, v.(meta).depth)
+		assert.Nil(v.(meta).referrer)
+
+		v, ok = pages.Load("/b.html")
+		assert.True(ok)
+		assert.Equal(2, v.(meta).depth)
+		assert.Equal(srv.URL+"/products.html", v.(meta).referrer.String())
+	})
+
+	t.Run("run with max pages", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.budgets.maxPages = 2
+		err := eng.Run(ctx, srv.URL)
+
+		var berr *BudgetError
+		assert.True(errors.Is(err, ErrBudgetExceeded))
+		assert.True(errors.As(err, &berr))
+		assert.Equal(BudgetPages, berr.Budget)
+		assert.Equal(2, len(visitor.paths))
+	})
+
+	t.Run("run with max pages workers", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var srv = server(t, "example.com")
+
+		eng, err := NewEngine(EngineConfig{
+			Workers:  5,
+			MaxPages: 3,
+			Scraper:  visitor,
+		})
+		assert.NoError(err)
+
+		err = eng.Run(ctx, srv.URL)
+		assert.ErrorIs(err, ErrBudgetExceeded)
+		assert.Equal(3, len(visitor.paths))
+	})
+
+	t.Run("run with max pages exact", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.budgets.maxPages = 5
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal(5, len(visitor.paths))
+	})
+
+	t.Run("run with max duration", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var srv = server(t, "example.com")
+
+		eng := setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			time.Sleep(20 * time.Millisecond)
+			return visitor.Scrape(ctx, p)
+		}))
+
+		eng.maxTime = 30 * time.Millisecond
+		err := eng.Run(ctx, srv.URL)
+
+		var berr *BudgetError
+		assert.True(errors.As(err, &berr))
+		assert.Equal(BudgetDuration, berr.Budget)
+		assert.True(len(visitor.paths) < 5)
+	})
+
+	t.Run("run with max bytes", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.budgets.maxBytes = 1
+		err := eng.Run(ctx, srv.URL)
+
+		var berr *BudgetError
+		assert.True(errors.As(err, &berr))
+		assert.Equal(BudgetBytes, berr.Budget)
+		assert.Equal([]string{"/"}, visitor.paths)
+	})
+
+	t.Run("run with max host pages", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.budgets.maxHostPages = 2
+		err := eng.Run(ctx, srv.URL)
+
+		assert.NoError(err)
+		assert.Equal(2, len(visitor.paths))
+	})
+
+	t.Run("run with logger", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var buf bytes.Buffer
+		var srv = server(t, "example.com")
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: &visitor{},
+			Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				Level: slog.LevelDebug,
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+
+		var msgs = make(map[string]int)
+		var dec = json.NewDecoder(&buf)
+
+		for dec.More() {
+			var rec map[string]any
+			assert.NoError(dec.Decode(&rec))
+			msgs[rec["msg"].(string)]++
+
+			switch rec["msg"] {
+			case "ant: scrape", "ant: fetch":
+				assert.Contains(rec["url"], srv.URL)
+				assert.Equal(strings.TrimPrefix(srv.URL, "http://"), rec["host"])
+				assert.Equal(float64(200), rec["status"])
+				assert.Equal(float64(1), rec["attempt"])
+				assert.Contains(rec, "duration")
+			}
+		}
+
+		assert.Equal(5, msgs["ant: scrape"])
+		assert.Equal(5, msgs["ant: fetch"])
+		assert.Equal(1, msgs["ant: robots disallowed"])
+		assert.Equal(1, msgs["robots: fetched robots.txt"])
+	})
+
 	t.Run("run aborts when a scraper errors", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -127,6 +399,103 @@ func TestEngine(t *testing.T) {
 		assert.EqualError(err, `ant: enqueue - ant: dedupe - boom`)
 	})
 
+	t.Run("retry temporary scraper error", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var calls int64
+		var eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			if atomic.AddInt64(&calls, 1) < 3 {
+				return nil, temporaryError{}
+			}
+			return visitor.Scrape(ctx, p)
+		}))
+		var srv = server(t, "example.com")
+
+		eng.minWait = time.Nanosecond
+		err := eng.Run(ctx, srv.URL)
+
+		assert.NoError(err)
+		assert.Equal(5, len(visitor.paths))
+		assert.Equal(int64(7), atomic.LoadInt64(&calls))
+	})
+
+	t.Run("retry temporary scraper error max attempts", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var calls int64
+		var eng = setup(t, scraperFunc(func(context.Context, *Page) (URLs, error) {
+			atomic.AddInt64(&calls, 1)
+			return nil, temporaryError{}
+		}))
+		var srv = server(t, "example.com")
+
+		eng.attempts = 2
+		eng.minWait = time.Nanosecond
+		err := eng.Run(ctx, srv.URL)
+
+		assert.Error(err)
+		assert.Contains(err.Error(), "max attempts of 2 reached - temporary")
+		assert.Equal(int64(2), atomic.LoadInt64(&calls))
+	})
+
+	t.Run("no retry on permanent scraper error", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var scraper = &scraperError{n: 1, err: io.ErrUnexpectedEOF}
+		var eng = setup(t, scraper)
+		var srv = server(t, "example.com")
+
+		err := eng.Run(ctx, srv.URL)
+
+		assert.Error(err)
+		assert.Equal(uint64(1), atomic.LoadUint64(&scraper.seq))
+	})
+
+	t.Run("retry temporary dedupe error", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+		var deduper = &dedupeTemporary{n: 2, deduper: DedupeMap()}
+
+		eng.deduper = deduper
+		eng.minWait = time.Nanosecond
+		err := eng.Run(ctx, srv.URL)
+
+		assert.NoError(err)
+		assert.Equal(5, len(visitor.paths))
+	})
+
+	t.Run("retry temporary dedupe error max attempts", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var eng = setup(t, &visitor{})
+		var deduper = &dedupeTemporary{n: 10, deduper: DedupeMap()}
+
+		eng.deduper = deduper
+		eng.attempts = 3
+		eng.minWait = time.Nanosecond
+		err := eng.Run(ctx, "http://:9999")
+
+		assert.Error(err)
+		assert.EqualError(err, `ant: enqueue - ant: dedupe - ant: max attempts of 3 reached - temporary`)
+		assert.Equal(3, deduper.calls)
+	})
+
+	t.Run("invalid backoff", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := NewEngine(EngineConfig{
+			Scraper:    &visitor{},
+			MinBackoff: time.Second,
+			MaxBackoff: time.Millisecond,
+		})
+
+		assert.EqualError(err, `ant: min backoff must be less than max backoff`)
+	})
+
 	t.Run("limit error", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -258,3 +627,42 @@ type dedupeError struct{}
 func (d dedupeError) Dedupe(ctx context.Context, urls URLs) (URLs, error) {
 	return nil, errors.New("boom")
 }
+
+// ScraperFunc implements a scraper.
+type scraperFunc func(context.Context, *Page) (URLs, error)
+
+// Scrape implementation.
+func (f scraperFunc) Scrape(ctx context.Context, p *Page) (URLs, error) {
+	return f(ctx, p)
+}
+
+// TemporaryError implements a temporary error.
+type temporaryError struct{}
+
+// Error implementation.
+func (temporaryError) Error() string { return "temporary" }
+
+// Temporary implementation.
+func (temporaryError) Temporary() bool { return true }
+
+// DedupeTemporary returns a temporary error
+// on the first N calls to dedupe.
+type dedupeTemporary struct {
+	mu      sync.Mutex
+	n       int
+	calls   int
+	deduper Deduper
+}
+
+// Dedupe implementation.
+func (d *dedupeTemporary) Dedupe(ctx context.Context, urls URLs) (URLs, error) {
+	d.mu.Lock()
+	d.calls++
+	fail := d.calls <= d.n
+	d.mu.Unlock()
+
+	if fail {
+		return nil, temporaryError{}
+	}
+	return d.deduper.Dedupe(ctx, urls)
+}
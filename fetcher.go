@@ -1,11 +1,18 @@
 package ant
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -30,8 +37,12 @@ var (
 	DefaultFetcher = &Fetcher{
 		Client:    DefaultClient,
 		UserAgent: UserAgent,
+		Throttle:  &Throttle{},
 	}
 
+	// Discard is a logger that discards all records.
+	discard = slog.New(slog.DiscardHandler)
+
 	// MinBackoff to use when the fetcher retries.
 	//
 	// Must be less than MaxBackoff, otherwise
@@ -68,6 +79,22 @@ func (err FetchError) Temporary() bool {
 		err.Status == 429 // Too many requests.
 }
 
+// ContentTypeError represents a response
+// with a content type that is not allowed.
+type ContentTypeError struct {
+	URL         *url.URL
+	Status      int
+	ContentType string
+}
+
+// Error implementation.
+func (err ContentTypeError) Error() string {
+	return fmt.Sprintf("ant: fetch %q - content type %q is not allowed",
+		err.URL,
+		err.ContentType,
+	)
+}
+
 // Fetch fetches a page from URL.
 func Fetch(ctx context.Context, rawurl string) (*Page, error) {
 	u, err := url.Parse(rawurl)
@@ -78,12 +105,32 @@ func Fetch(ctx context.Context, rawurl string) (*Page, error) {
 }
 
 // Fetcher implements a page fetcher.
+//
+// A fetcher must not be copied after first use.
 type Fetcher struct {
 	// Client is the client to use.
 	//
 	// If nil, ant.DefaultClient is used.
 	Client Client
 
+	// Sessions is the cookie sessions to use.
+	//
+	// When set, the fetcher sends the cookies of the request's
+	// session and adds the cookies of the response to it, see
+	// `Sessions` for how sessions are selected.
+	//
+	// If nil, cookies are handled by the client.
+	Sessions *Sessions
+
+	// Middleware is the client middleware chain.
+	//
+	// The middleware wraps the client in order, the first
+	// middleware is the outermost, every attempt goes through
+	// the chain, including HEAD preflight requests.
+	//
+	// If empty, requests are sent with the client directly.
+	Middleware []Middleware
+
 	// UserAgent is the user agent to use.
 	//
 	// It implements the fmt.Stringer interface
@@ -112,6 +159,106 @@ type Fetcher struct {
 	//
 	// Defaults to `1s`.
 	MaxBackoff time.Duration
+
+	// RetryPolicy is the retry policy to use.
+	//
+	// If nil, temporary failures are retried with a backoff
+	// that grows quadratically from MinBackoff to MaxBackoff.
+	RetryPolicy RetryPolicy
+
+	// RetryBudget limits the retries per host.
+	//
+	// When a host exhausts the budget its failures are not
+	// retried until it recovers, see `RetryBudget`.
+	//
+	// If nil, retries are not limited.
+	RetryBudget *RetryBudget
+
+	// Throttle is the per-host gate to use.
+	//
+	// When a host responds with a 429 or 503 status the fetcher
+	// closes its gate, all requests to the host made with the
+	// throttle wait until it opens again, see `Throttle`.
+	//
+	// If nil, only the failed request waits before it is retried.
+	Throttle *Throttle
+
+	// MaxRetryAfter is the maximum `Retry-After` delay to honour.
+	//
+	// When a server asks for a longer delay the request is not
+	// retried and the host's gate is closed for MaxRetryAfter.
+	//
+	// When <= 0, it defaults to `1m`.
+	MaxRetryAfter time.Duration
+
+	// AllowedTypes is the set of allowed media types.
+	//
+	// The types are matched against the response's Content-Type
+	// header before the body is read, a type can be a wildcard
+	// such as "image/*", responses without a Content-Type are
+	// allowed. When set, the fetcher sends the types in the
+	// Accept header.
+	//
+	// When a response is not allowed, the fetcher returns
+	// a `*ContentTypeError`, the engine skips the page and
+	// includes the error in its summary.
+	//
+	// If empty, all types are allowed.
+	AllowedTypes []string
+
+	// Preflight enables HEAD preflight requests.
+	//
+	// When true and `AllowedTypes` is set, the fetcher sends a
+	// HEAD request before every GET request and skips the GET if
+	// the content type is not allowed, if the HEAD request fails
+	// the fetcher sends the GET request as usual.
+	Preflight bool
+
+	// MaxBodySize is the maximum amount of body bytes to read.
+	//
+	// Responses with a larger Content-Length are rejected with
+	// `ErrBodyTooLarge` before their body is read, reading a larger
+	// body fails with `ErrBodyTooLarge` once the limit is reached,
+	// the engine handles both as fetch errors.
+	//
+	// If <= 0, the body size is not limited.
+	MaxBodySize int64
+
+	// TruncateBody truncates bodies at MaxBodySize.
+	//
+	// When true, larger bodies are not rejected, reading them
+	// stops at the limit and `Page.Truncated()` returns true.
+	TruncateBody bool
+
+	// IdleTimeout is the maximum duration to wait
+	// for body data between reads.
+	//
+	// When a read blocks for longer the body is closed
+	// and the read fails with `ErrBodyTimeout`.
+	//
+	// If <= 0, reads do not time out.
+	IdleTimeout time.Duration
+
+	// MaxBufferSize is the maximum amount of body bytes
+	// to buffer per page.
+	//
+	// The page's body is buffered when it is first read which
+	// allows scrapers to use `Page.Bytes()` and query the page,
+	// larger bodies are streamed and can only be read once.
+	//
+	// When <= 0, it defaults to 10MB.
+	MaxBufferSize int64
+
+	// Logger is the logger to use.
+	//
+	// The fetcher logs every request attempt at debug
+	// level and retries at warn level.
+	//
+	// If nil, the fetcher does not log.
+	Logger *slog.Logger
+
+	once    sync.Once
+	chained Client
 }
 
 // Fetch fetches a page by URL.
@@ -129,13 +276,69 @@ type Fetcher struct {
 // be read until EOF and closed so that the client can re-use the
 // underlying TCP connection.
 func (f *Fetcher) Fetch(ctx context.Context, url *URL) (*Page, error) {
+	return f.FetchRequest(ctx, NewRequest(url))
+}
+
+// FetchRequest fetches a page by request.
+//
+// The method behaves like `Fetch()`, it sends the request's
+// method, headers and body, the body is sent again on retries.
+func (f *Fetcher) FetchRequest(ctx context.Context, r *Request) (*Page, error) {
+	var url = r.URL
 	var maxAttempts = f.maxAttempts()
 	var attempt int
 	var resp *http.Response
+	var start time.Time
+	var wait time.Duration
 	var err error
 
+	policy, err := f.retryPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.preflight(ctx, r); err != nil {
+		return nil, err
+	}
+
+	if b := f.RetryBudget; b != nil {
+		b.deposit(url.Host)
+	}
+
 	for {
-		if attempt++; attempt > maxAttempts {
+		attempt++
+		start = time.Now()
+		resp, err = f.fetch(ctx, r)
+		f.log(ctx, url, attempt, resp, time.Since(start))
+
+		if err == nil {
+			break
+		}
+
+		var cterr *ContentTypeError
+		if errors.As(err, &cterr) {
+			resp.Body.Close()
+			return nil, err
+		}
+
+		f.discard(resp)
+
+		var ok bool
+		wait, ok = policy.Retry(Attempt{
+			Request:  r,
+			Number:   attempt,
+			Response: resp,
+			Err:      err,
+			Wait:     wait,
+		})
+
+		if resp != nil && (resp.StatusCode == 429 || resp.StatusCode == 503) {
+			delay, honoured := f.throttle(ctx, url, resp, wait)
+			wait = max(wait, delay)
+			ok = ok && honoured
+		}
+
+		if ok && attempt >= maxAttempts {
 			return nil, fmt.Errorf(
 				"ant: max attempts of %d reached - %w",
 				maxAttempts,
@@ -143,13 +346,19 @@ func (f *Fetcher) Fetch(ctx context.Context, url *URL) (*Page, error) {
 			)
 		}
 
-		if resp, err = f.fetch(ctx, url); err == nil {
-			break
-		}
-
-		f.discard(resp)
-		if isTemporary(err) {
-			if err := f.backoff(ctx, attempt); err != nil {
+		if ok {
+			if b := f.RetryBudget; b != nil && !b.withdraw(url.Host) {
+				return nil, fmt.Errorf("ant: retry budget of %q exhausted - %w", url.Host, err)
+			}
+			hooksFrom(ctx).retry(ctx, url, attempt, err)
+			f.logger().WarnContext(ctx, "ant: fetch retry",
+				"url", url.String(),
+				"host", url.Host,
+				"attempt", attempt,
+				"duration", wait,
+				"error", err,
+			)
+			if err := sleep(ctx, wait); err != nil {
 				return nil, err
 			}
 			continue
@@ -164,18 +373,73 @@ func (f *Fetcher) Fetch(ctx context.Context, url *URL) (*Page, error) {
 		return nil, err
 	}
 
+	if n := f.MaxBodySize; n > 0 && !f.TruncateBody && resp.ContentLength > n {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ant: fetch %q - %w", url, ErrBodyTooLarge)
+	}
+
+	body := newBody(resp.Body, f.MaxBodySize, f.TruncateBody, f.IdleTimeout)
+
 	return &Page{
-		URL:    resp.Request.URL,
-		Header: resp.Header,
-		body:   resp.Body,
+		URL:           resp.Request.URL,
+		Header:        resp.Header,
+		StatusCode:    resp.StatusCode,
+		RequestURL:    url,
+		Redirects:     redirects(resp),
+		FetchedAt:     start,
+		Duration:      time.Since(start),
+		ContentLength: resp.ContentLength,
+		Proto:         resp.Proto,
+		TLS:           resp.TLS,
+		body:          body,
+		guard:         body,
+		limit:         f.MaxBufferSize,
 	}, nil
 }
 
-// Fetch fetches a new page by URL.
-func (f *Fetcher) fetch(ctx context.Context, url *URL) (*http.Response, error) {
-	var client = f.client()
+// Redirects returns the redirect chain of resp.
+//
+// The client sets the response that caused a redirect on the
+// request that followed it, the method walks these responses back
+// to the original request which works with any configured client.
+func redirects(resp *http.Response) []Redirect {
+	var ret []Redirect
+
+	for r := resp.Request.Response; r != nil; r = r.Request.Response {
+		ret = append(ret, Redirect{
+			URL:        r.Request.URL,
+			StatusCode: r.StatusCode,
+		})
+	}
+
+	slices.Reverse(ret)
+	return ret
+}
+
+// Fetch fetches a new page by request.
+func (f *Fetcher) fetch(ctx context.Context, r *Request) (*http.Response, error) {
+	var jar = f.Sessions.jar(ctx, r)
+	var client, cookies = f.client(jar)
+	var method = r.Method
+	var body io.Reader
+
+	if method == "" {
+		method = "GET"
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
+	if r.Body != nil {
+		body = bytes.NewReader(r.Body)
+	}
+
+	if err := f.Throttle.wait(ctx, r.URL.Host); err != nil {
+		return nil, err
+	}
+
+	if jar != nil && cookies {
+		ctx = context.WithValue(ctx, jarKey{}, jar)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.URL.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("ant: new request - %w", err)
 	}
@@ -184,8 +448,22 @@ func (f *Fetcher) fetch(ctx context.Context, url *URL) (*http.Response, error) {
 		req.Header[k] = v
 	}
 
+	for k, v := range r.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+
+	if jar != nil && !cookies {
+		for _, c := range jar.Cookies(req.URL) {
+			req.AddCookie(c)
+		}
+	}
+
 	resp, err := client.Do(req)
 
+	if jar != nil && !cookies && resp != nil {
+		setCookies(jar, resp)
+	}
+
 	if err != nil {
 		return resp, fmt.Errorf("ant: %s %q - %w", req.Method, req.URL, err)
 	}
@@ -197,9 +475,99 @@ func (f *Fetcher) fetch(ctx context.Context, url *URL) (*http.Response, error) {
 		}
 	}
 
+	if ct := resp.Header.Get("Content-Type"); !f.allowed(ct) {
+		return resp, &ContentTypeError{
+			URL:         resp.Request.URL,
+			Status:      resp.StatusCode,
+			ContentType: ct,
+		}
+	}
+
 	return resp, nil
 }
 
+// Throttle throttles the host of url after resp.
+//
+// The method closes the host's gate for the delay of the
+// Retry-After header or wait if the header is missing, it
+// returns the delay and false if the delay is too long.
+func (f *Fetcher) throttle(ctx context.Context, url *URL, resp *http.Response, wait time.Duration) (time.Duration, bool) {
+	var limit = f.maxRetryAfter()
+	var delay, ok = retryAfter(resp.Header, time.Now())
+
+	if !ok {
+		delay = wait
+	}
+
+	honoured := delay <= limit
+	delay = min(delay, limit)
+
+	f.Throttle.delay(url.Host, delay)
+	hooksFrom(ctx).throttle(ctx, url, delay)
+	f.logger().WarnContext(ctx, "ant: throttle",
+		"url", url.String(),
+		"host", url.Host,
+		"status", resp.StatusCode,
+		"duration", delay,
+	)
+
+	return delay, honoured
+}
+
+// Preflight sends a HEAD request for r.
+//
+// The method returns a `*ContentTypeError` if the content type
+// is not allowed, all other errors are ignored unless the
+// context is canceled.
+func (f *Fetcher) preflight(ctx context.Context, r *Request) error {
+	if !f.Preflight || len(f.AllowedTypes) == 0 {
+		return nil
+	}
+
+	if r.Method != "" && r.Method != "GET" {
+		return nil
+	}
+
+	head := *r
+	head.Method = "HEAD"
+
+	resp, err := f.fetch(ctx, &head)
+	f.discard(resp)
+
+	var cterr *ContentTypeError
+	if errors.As(err, &cterr) {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Allowed returns true if the content type ct is allowed.
+func (f *Fetcher) allowed(ct string) bool {
+	if len(f.AllowedTypes) == 0 || ct == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range f.AllowedTypes {
+		t = strings.ToLower(t)
+		if t == mt || t == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mt, prefix+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Discard discards the given response.
 func (f *Fetcher) discard(r *http.Response) {
 	if r != nil {
@@ -208,6 +576,31 @@ func (f *Fetcher) discard(r *http.Response) {
 	}
 }
 
+// Log logs a request attempt.
+func (f *Fetcher) log(ctx context.Context, url *URL, attempt int, resp *http.Response, d time.Duration) {
+	var status int
+
+	if resp != nil {
+		status = resp.StatusCode
+	}
+
+	f.logger().DebugContext(ctx, "ant: fetch",
+		"url", url.String(),
+		"host", url.Host,
+		"status", status,
+		"attempt", attempt,
+		"duration", d,
+	)
+}
+
+// Logger returns the logger to use.
+func (f *Fetcher) logger() *slog.Logger {
+	if f.Logger != nil {
+		return f.Logger
+	}
+	return discard
+}
+
 // MaxAttempts returns the max attempts.
 func (f *Fetcher) maxAttempts() int {
 	if f.MaxAttempts > 0 {
@@ -221,6 +614,9 @@ func (f *Fetcher) headers() http.Header {
 	var hdr = make(http.Header)
 
 	hdr.Set("Accept", "text/html; charset=UTF-8")
+	if len(f.AllowedTypes) > 0 {
+		hdr.Set("Accept", strings.Join(f.AllowedTypes, ", "))
+	}
 	hdr.Set("User-Agent", f.userAgent())
 
 	return hdr
@@ -234,39 +630,88 @@ func (f *Fetcher) userAgent() string {
 	return UserAgent.String()
 }
 
-// Client returns the client to use.
-func (f *Fetcher) client() Client {
+// Client returns the client to use with jar.
+//
+// The middleware chain is built once, the jar is passed with the
+// request's context to the client below the chain.
+//
+// When the client is an `*http.Client` without a jar, the method
+// returns true and the client uses jar, which lets the client handle
+// cookies of redirects, otherwise the caller handles cookies.
+func (f *Fetcher) client(jar *Jar) (Client, bool) {
+	f.once.Do(func() {
+		f.chained = chain(ClientFunc(f.do), f.Middleware)
+	})
+
+	hc, ok := f.base().(*http.Client)
+	return f.chained, ok && jar != nil && hc.Jar == nil
+}
+
+// Do sends req with the configured client.
+//
+// When the client is an `*http.Client` without a jar, the method
+// uses a copy of it with the jar of the request's context.
+func (f *Fetcher) do(req *http.Request) (*http.Response, error) {
+	var c = f.base()
+
+	if hc, ok := c.(*http.Client); ok && hc.Jar == nil {
+		if jar, ok := req.Context().Value(jarKey{}).(*Jar); ok {
+			client := *hc
+			client.Jar = jar
+			c = &client
+		}
+	}
+
+	return c.Do(req)
+}
+
+// Base returns the configured client.
+func (f *Fetcher) base() Client {
 	if f.Client != nil {
 		return f.Client
 	}
 	return DefaultClient
 }
 
-// Backoff performs the backoff.
-//
-// TODO: configurable backoff duration, jitter...?
-func (f *Fetcher) backoff(ctx context.Context, attempt int) error {
-	var min = f.minBackoff()
-	var max = f.maxBackoff()
-	var dur = time.Duration(attempt*attempt) * min
+// JarKey is the context key of the request's cookie jar.
+type jarKey struct{}
 
-	if min >= max {
-		return fmt.Errorf("ant: min backoff must be greater than max backoff")
+// SetCookies adds the cookies of resp and all
+// responses that redirected to it to jar.
+func setCookies(jar *Jar, resp *http.Response) {
+	var all []*http.Response
+
+	for r := resp; r != nil; r = r.Request.Response {
+		all = append(all, r)
 	}
 
-	if dur > max {
-		dur = max
+	for j := len(all) - 1; j >= 0; j-- {
+		if cookies := all[j].Cookies(); len(cookies) > 0 {
+			jar.SetCookies(all[j].Request.URL, cookies)
+		}
 	}
+}
 
-	var timer = time.NewTimer(dur)
-	defer timer.Stop()
+// MaxRetryAfter returns the maximum Retry-After delay.
+func (f *Fetcher) maxRetryAfter() time.Duration {
+	if f.MaxRetryAfter > 0 {
+		return f.MaxRetryAfter
+	}
+	return time.Minute
+}
 
-	select {
-	case <-timer.C:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+// RetryPolicy returns the retry policy.
+func (f *Fetcher) retryPolicy() (RetryPolicy, error) {
+	if f.RetryPolicy != nil {
+		return f.RetryPolicy, nil
+	}
+
+	min, max := f.minBackoff(), f.maxBackoff()
+	if min >= max {
+		return nil, fmt.Errorf("ant: min backoff must be less than max backoff")
 	}
+
+	return quadratic{min, max}, nil
 }
 
 // MinBackoff returns the min backoff.
@@ -284,9 +729,3 @@ func (f *Fetcher) maxBackoff() time.Duration {
 	}
 	return maxBackoff
 }
-
-// IsTemporary returns true if the error is temporary.
-func isTemporary(err error) bool {
-	t, ok := err.(interface{ Temporary() bool })
-	return ok && t.Temporary()
-}
@@ -2,9 +2,11 @@ package ant
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -111,6 +113,55 @@ func TestFetcher(t *testing.T) {
 		assert.Contains(err.Error(), `503`)
 	})
 
+	t.Run("fetch retry policy", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var reqs uint64
+		var url = serve(t, func(w http.ResponseWriter) {
+			if atomic.AddUint64(&reqs, 1) == 3 {
+				w.WriteHeader(200)
+				return
+			}
+			w.WriteHeader(502)
+		})
+
+		fetcher := &Fetcher{
+			RetryPolicy: ExponentialJitter{
+				RetryOn: RetryOn{Statuses: []int{502}},
+				Base:    time.Millisecond,
+				Max:     2 * time.Millisecond,
+			},
+		}
+
+		p, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		assert.NotNil(p)
+		assert.NoError(p.close())
+		assert.Equal(uint64(3), reqs)
+	})
+
+	t.Run("fetch retry budget", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var reqs uint64
+		var url = serve(t, func(w http.ResponseWriter) {
+			atomic.AddUint64(&reqs, 1)
+			w.WriteHeader(503)
+		})
+
+		fetcher := &Fetcher{
+			MinBackoff:  time.Millisecond,
+			MaxBackoff:  2 * time.Millisecond,
+			RetryBudget: &RetryBudget{Max: 1},
+		}
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.Error(err)
+		assert.Contains(err.Error(), "retry budget of")
+		assert.Contains(err.Error(), "exhausted")
+		assert.Equal(uint64(2), reqs)
+	})
+
 	t.Run("sends headers", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -125,6 +176,128 @@ func TestFetcher(t *testing.T) {
 		assert.Equal(UserAgent.String(), req.Header.Get("User-Agent"))
 	})
 
+	t.Run("fetch request", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var fetcher = &Fetcher{}
+		var got []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			got = append(got, r.Method+" "+string(body)+" "+r.Header.Get("Accept"))
+			if len(got) == 1 {
+				w.WriteHeader(503)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		req := NewRequest(parseURL(t, srv.URL))
+		req.Method = "PUT"
+		req.Body = []byte("a=b")
+		req.Header = http.Header{"Accept": {"application/json"}}
+
+		_, err := fetcher.FetchRequest(ctx, req)
+		assert.NoError(err)
+		assert.Equal([]string{
+			"PUT a=b application/json",
+			"PUT a=b application/json",
+		}, got)
+	})
+
+	t.Run("page metadata", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mux = http.NewServeMux()
+
+		mux.Handle("/a", http.RedirectHandler("/b", 301))
+		mux.Handle("/b", http.RedirectHandler("/c", 302))
+		mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "hello")
+		})
+
+		srv := httptest.NewTLSServer(mux)
+		t.Cleanup(srv.Close)
+
+		fetcher := &Fetcher{Client: srv.Client()}
+		before := time.Now()
+		page, err := fetcher.Fetch(ctx, parseURL(t, srv.URL+"/a"))
+		assert.NoError(err)
+		defer page.close()
+
+		assert.Equal(200, page.StatusCode)
+		assert.Equal(srv.URL+"/a", page.RequestURL.String())
+		assert.Equal(srv.URL+"/c", page.URL.String())
+		assert.Equal([]Redirect{
+			{URL: parseURL(t, srv.URL+"/a"), StatusCode: 301},
+			{URL: parseURL(t, srv.URL+"/b"), StatusCode: 302},
+		}, page.Redirects)
+		assert.False(page.FetchedAt.Before(before))
+		assert.True(page.Duration > 0)
+		assert.Equal(int64(5), page.ContentLength)
+		assert.Equal("HTTP/1.1", page.Proto)
+		assert.NotNil(page.TLS)
+	})
+
+	t.Run("allowed types", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var fetcher = &Fetcher{AllowedTypes: []string{"text/html", "image/*"}}
+		var accept string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept = r.Header.Get("Accept")
+			w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		}))
+		t.Cleanup(srv.Close)
+
+		for _, ct := range []string{"text/html; charset=utf-8", "image/png", ""} {
+			page, err := fetcher.Fetch(ctx, parseURL(t, srv.URL+"?type="+url.QueryEscape(ct)))
+			assert.NoError(err)
+			assert.NotNil(page)
+			page.close()
+		}
+
+		_, err := fetcher.Fetch(ctx, parseURL(t, srv.URL+"?type=application/pdf"))
+		var cterr *ContentTypeError
+		assert.True(errors.As(err, &cterr))
+		assert.Equal(200, cterr.Status)
+		assert.Equal("application/pdf", cterr.ContentType)
+		assert.Equal("text/html, image/*", accept)
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var fetcher = &Fetcher{AllowedTypes: []string{"text/html"}, Preflight: true}
+		var got []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Method+" "+r.URL.Path)
+			switch r.URL.Path {
+			case "/doc.pdf":
+				w.Header().Set("Content-Type", "application/pdf")
+			case "/no-head":
+				if r.Method == "HEAD" {
+					w.WriteHeader(405)
+				}
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := fetcher.Fetch(ctx, parseURL(t, srv.URL+"/doc.pdf"))
+		assert.IsType(&ContentTypeError{}, err)
+
+		page, err := fetcher.Fetch(ctx, parseURL(t, srv.URL+"/no-head"))
+		assert.NoError(err)
+		page.close()
+
+		assert.Equal([]string{
+			"HEAD /doc.pdf",
+			"HEAD /no-head",
+			"GET /no-head",
+		}, got)
+	})
+
 	t.Run("custom user-agent", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -0,0 +1,342 @@
+package ant
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/yields/ant/internal/scan"
+	"golang.org/x/net/html"
+)
+
+// Input represents a form control.
+type Input struct {
+	// Name is the control's name.
+	Name string
+
+	// Type is the control's type, e.g. "text", "hidden",
+	// "checkbox", "submit", "select" or "textarea".
+	Type string
+
+	// Value is the control's default value.
+	//
+	// The value of a select is its first selected option.
+	Value string
+
+	// Checked is true if a checkbox or radio button is checked.
+	Checked bool
+
+	// Options contains the option values of a select.
+	Options []string
+}
+
+// Form represents an HTML form.
+//
+// The form's values are initialized with the default values
+// of its controls, as a browser would submit them, use `Set()`
+// to change them and `Request()` to build the submission.
+type Form struct {
+	// Action is the URL to submit the form to.
+	//
+	// It is resolved against the page's URL, when the
+	// form has no action it is the page's URL.
+	Action *URL
+
+	// Method is the HTTP method, "GET" or "POST".
+	Method string
+
+	// Enctype is the encoding of POST submissions.
+	//
+	// It is either "application/x-www-form-urlencoded"
+	// or "multipart/form-data".
+	Enctype string
+
+	// Inputs contains all named controls
+	// of the form in document order.
+	Inputs []Input
+
+	values url.Values
+}
+
+// Forms returns all forms on the page.
+//
+// The method returns an empty list if the
+// page could not be parsed.
+func (p *Page) Forms() []*Form {
+	var nodes = p.Query("form")
+	var ret = make([]*Form, 0, len(nodes))
+
+	for _, n := range nodes {
+		ret = append(ret, parseForm(p.URL, n))
+	}
+
+	return ret
+}
+
+// Values returns a copy of the form's values.
+func (f *Form) Values() url.Values {
+	var ret = make(url.Values, len(f.values))
+
+	for k, v := range f.values {
+		ret[k] = append([]string(nil), v...)
+	}
+
+	return ret
+}
+
+// Set sets the value of name, replacing existing values.
+func (f *Form) Set(name, value string) *Form {
+	f.values.Set(name, value)
+	return f
+}
+
+// Add adds value to name.
+func (f *Form) Add(name, value string) *Form {
+	f.values.Add(name, value)
+	return f
+}
+
+// Del deletes the values of name, e.g. to uncheck a checkbox.
+func (f *Form) Del(name string) *Form {
+	f.values.Del(name)
+	return f
+}
+
+// Request returns the request that submits the form.
+//
+// GET forms replace the query of the action URL with
+// the values, POST forms are encoded using the form's
+// enctype, files are not supported.
+func (f *Form) Request() *Request {
+	var u = *f.Action
+
+	if f.Method != "POST" {
+		u.RawQuery = f.values.Encode()
+		return &Request{Method: "GET", URL: &u}
+	}
+
+	if f.Enctype != "multipart/form-data" {
+		return FormRequest(&u, f.values)
+	}
+
+	var buf bytes.Buffer
+	var w = multipart.NewWriter(&buf)
+
+	for _, name := range f.names() {
+		for _, v := range f.values[name] {
+			w.WriteField(name, v)
+		}
+	}
+
+	w.Close()
+
+	return &Request{
+		Method: "POST",
+		URL:    &u,
+		Header: http.Header{"Content-Type": {w.FormDataContentType()}},
+		Body:   buf.Bytes(),
+	}
+}
+
+// Names returns the names of all values.
+//
+// The names are ordered by their first input, names
+// without an input are sorted and follow the inputs.
+func (f *Form) names() []string {
+	var ret = make([]string, 0, len(f.values))
+	var seen = make(map[string]bool, len(f.values))
+
+	for _, in := range f.Inputs {
+		if _, ok := f.values[in.Name]; ok && !seen[in.Name] {
+			seen[in.Name] = true
+			ret = append(ret, in.Name)
+		}
+	}
+
+	var rest = make([]string, 0, len(f.values)-len(ret))
+	for name := range f.values {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(ret, rest...)
+}
+
+// ParseForm parses the form node n of a page at base.
+func parseForm(base *URL, n *html.Node) *Form {
+	var f = &Form{
+		Action:  base,
+		Method:  "GET",
+		Enctype: "application/x-www-form-urlencoded",
+		values:  make(url.Values),
+	}
+
+	if action, ok := scan.Attr(n, "action"); ok && strings.TrimSpace(action) != "" {
+		if u, err := url.Parse(strings.TrimSpace(action)); err == nil {
+			f.Action = base.ResolveReference(u)
+		}
+	}
+
+	if method, _ := scan.Attr(n, "method"); strings.EqualFold(method, "post") {
+		f.Method = "POST"
+	}
+
+	if enctype, _ := scan.Attr(n, "enctype"); strings.EqualFold(enctype, "multipart/form-data") {
+		f.Enctype = "multipart/form-data"
+	}
+
+	walk(n, func(c *html.Node) {
+		if in, ok := parseInput(c); ok {
+			f.Inputs = append(f.Inputs, in)
+			f.init(c, in)
+		}
+	})
+
+	// Avoid sharing an unresolved action with the page.
+	if f.Action == base {
+		u := *base
+		f.Action = &u
+	}
+
+	return f
+}
+
+// Init adds the default values of input in of node n.
+func (f *Form) init(n *html.Node, in Input) {
+	switch in.Type {
+	case "submit", "button", "reset", "image", "file":
+	case "checkbox", "radio":
+		if in.Checked {
+			f.values.Add(in.Name, in.Value)
+		}
+	case "select":
+		var selected = selectedOptions(n)
+
+		if _, multiple := scan.Attr(n, "multiple"); !multiple && len(selected) == 0 && len(in.Options) > 0 {
+			selected = in.Options[:1]
+		}
+
+		for _, v := range selected {
+			f.values.Add(in.Name, v)
+		}
+	default:
+		f.values.Add(in.Name, in.Value)
+	}
+}
+
+// ParseInput parses a form control node.
+//
+// The method returns false if n is not a named
+// control or if it is disabled.
+func parseInput(n *html.Node) (Input, bool) {
+	var in Input
+
+	if n.Type != html.ElementNode {
+		return in, false
+	}
+
+	if _, disabled := scan.Attr(n, "disabled"); disabled {
+		return in, false
+	}
+
+	if in.Name, _ = scan.Attr(n, "name"); in.Name == "" {
+		return in, false
+	}
+
+	switch n.Data {
+	case "input":
+		in.Type, _ = scan.Attr(n, "type")
+		in.Type = strings.ToLower(in.Type)
+		in.Value, _ = scan.Attr(n, "value")
+		_, in.Checked = scan.Attr(n, "checked")
+
+		if in.Type == "" {
+			in.Type = "text"
+		}
+
+		if in.Type == "checkbox" || in.Type == "radio" {
+			if _, ok := scan.Attr(n, "value"); !ok {
+				in.Value = "on"
+			}
+		}
+
+	case "select":
+		in.Type = "select"
+		walk(n, func(c *html.Node) {
+			if c.Type == html.ElementNode && c.Data == "option" {
+				in.Options = append(in.Options, optionValue(c))
+			}
+		})
+		if selected := selectedOptions(n); len(selected) > 0 {
+			in.Value = selected[0]
+		} else if len(in.Options) > 0 {
+			in.Value = in.Options[0]
+		}
+
+	case "textarea":
+		in.Type = "textarea"
+		in.Value = text(n)
+
+	case "button":
+		in.Type, _ = scan.Attr(n, "type")
+		in.Type = strings.ToLower(in.Type)
+		in.Value, _ = scan.Attr(n, "value")
+		if in.Type == "" {
+			in.Type = "submit"
+		}
+
+	default:
+		return in, false
+	}
+
+	return in, true
+}
+
+// SelectedOptions returns the values of all selected options of n.
+func selectedOptions(n *html.Node) []string {
+	var ret []string
+
+	walk(n, func(c *html.Node) {
+		if c.Type == html.ElementNode && c.Data == "option" {
+			if _, ok := scan.Attr(c, "selected"); ok {
+				ret = append(ret, optionValue(c))
+			}
+		}
+	})
+
+	return ret
+}
+
+// OptionValue returns the value of option n.
+func optionValue(n *html.Node) string {
+	if v, ok := scan.Attr(n, "value"); ok {
+		return v
+	}
+	return strings.TrimSpace(text(n))
+}
+
+// Text returns the text of all text nodes in n.
+func text(n *html.Node) string {
+	var sb strings.Builder
+
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	})
+
+	return sb.String()
+}
+
+// Walk calls fn with all descendants of n in document order.
+func walk(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		fn(c)
+		walk(c, fn)
+	}
+}
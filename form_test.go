@@ -0,0 +1,274 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForms(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		var assert = require.New(t)
+		var page = makePage(t, `
+			<form action="/login?next=home" method="post">
+				<input type="hidden" name="csrf" value="token">
+				<input name="user" value="ant">
+				<input type="password" name="pass">
+				<input type="checkbox" name="remember" checked>
+				<input type="checkbox" name="newsletter" value="yes">
+				<input type="radio" name="plan" value="free">
+				<input type="radio" name="plan" value="pro" checked>
+				<input name="disabled" value="x" disabled>
+				<input value="unnamed">
+				<select name="lang">
+					<option>en</option>
+					<option value="de" selected>German</option>
+				</select>
+				<select name="size">
+					<option value="s">small</option>
+					<option value="m">medium</option>
+				</select>
+				<textarea name="bio">hello</textarea>
+				<button name="action" value="login">login</button>
+			</form>
+			<form></form>
+		`)
+
+		forms := page.Forms()
+		assert.Len(forms, 2)
+
+		form := forms[0]
+		assert.Equal("https://example.com/login?next=home", form.Action.String())
+		assert.Equal("POST", form.Method)
+		assert.Equal("application/x-www-form-urlencoded", form.Enctype)
+		assert.Len(form.Inputs, 11)
+		assert.Equal(Input{Name: "lang", Type: "select", Value: "de", Options: []string{"en", "de"}}, form.Inputs[7])
+		assert.Equal(url.Values{
+			"csrf":     {"token"},
+			"user":     {"ant"},
+			"pass":     {""},
+			"remember": {"on"},
+			"plan":     {"pro"},
+			"lang":     {"de"},
+			"size":     {"s"},
+			"bio":      {"hello"},
+		}, form.Values())
+
+		empty := forms[1]
+		assert.Equal("https://example.com", empty.Action.String())
+		assert.Equal("GET", empty.Method)
+		assert.Empty(empty.Values())
+		assert.NotSame(page.URL, empty.Action)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		var assert = require.New(t)
+		var page = makePage(t, `
+			<form action="search?page=2">
+				<input name="q" value="go">
+				<input name="lang" value="en">
+			</form>
+		`)
+
+		req := page.Forms()[0].Set("q", "ant").Request()
+		assert.Equal("GET", req.Method)
+		assert.Equal("https://example.com/search?lang=en&q=ant", req.URL.String())
+		assert.Nil(req.Body)
+	})
+
+	t.Run("urlencoded", func(t *testing.T) {
+		var assert = require.New(t)
+		var page = makePage(t, `
+			<form action="/login" method="POST">
+				<input type="hidden" name="csrf" value="token">
+				<input type="checkbox" name="remember" checked>
+				<input name="user">
+			</form>
+		`)
+
+		req := page.Forms()[0].
+			Set("user", "ant").
+			Del("remember").
+			Request()
+		assert.Equal("POST", req.Method)
+		assert.Equal("https://example.com/login", req.URL.String())
+		assert.Equal("application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+		assert.Equal("csrf=token&user=ant", string(req.Body))
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		var assert = require.New(t)
+		var page = makePage(t, `
+			<form action="/upload" method="post" enctype="multipart/form-data">
+				<input type="hidden" name="csrf" value="token">
+				<input name="title">
+			</form>
+		`)
+
+		req := page.Forms()[0].
+			Set("title", "ant").
+			Add("tag", "go").
+			Request()
+		assert.Equal("POST", req.Method)
+
+		hreq, err := http.NewRequest(req.Method, req.URL.String(), strings.NewReader(string(req.Body)))
+		assert.NoError(err)
+		hreq.Header = req.Header
+		assert.NoError(hreq.ParseMultipartForm(1 << 20))
+		assert.Equal(url.Values{
+			"csrf":  {"token"},
+			"title": {"ant"},
+			"tag":   {"go"},
+		}, url.Values(hreq.MultipartForm.Value))
+	})
+
+	t.Run("multipart groups", func(t *testing.T) {
+		var assert = require.New(t)
+		var page = makePage(t, `
+			<form action="/upload" method="post" enctype="multipart/form-data">
+				<input type="radio" name="c" value="a" checked>
+				<input type="radio" name="c" value="b">
+				<input type="radio" name="c" value="c">
+				<input type="checkbox" name="tags" value="go" checked>
+				<input type="checkbox" name="tags" value="web">
+				<input type="checkbox" name="tags" value="ant" checked>
+			</form>
+		`)
+
+		req := page.Forms()[0].Request()
+
+		hreq, err := http.NewRequest(req.Method, req.URL.String(), strings.NewReader(string(req.Body)))
+		assert.NoError(err)
+		hreq.Header = req.Header
+		assert.NoError(hreq.ParseMultipartForm(1 << 20))
+		assert.Equal(url.Values{
+			"c":    {"a"},
+			"tags": {"go", "ant"},
+		}, url.Values(hreq.MultipartForm.Value))
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var fetcher = &Fetcher{Sessions: &Sessions{}}
+
+		page := makePage(t, `
+			<form action="/login" method="post">
+				<input name="user">
+			</form>
+		`)
+		page.URL = parseURL(t, srv.URL)
+
+		page, err := fetcher.FetchRequest(ctx, page.Forms()[0].Set("user", "ant").Request())
+		assert.NoError(err)
+		assert.Equal("/home", page.URL.Path)
+		page.close()
+
+		page, err = fetcher.Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.NoError(err)
+		assert.Equal("ant", page.Text("title"))
+		page.close()
+	})
+
+	t.Run("enqueue", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var titles []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+
+			switch r.URL.Path {
+			case "/":
+				io.WriteString(w, `
+					<title>index</title>
+					<form action="/search" method="post">
+						<input type="hidden" name="csrf" value="token">
+						<input name="q">
+					</form>
+				`)
+
+			case "/search":
+				r.ParseForm()
+				if r.PostForm.Get("csrf") != "token" {
+					w.WriteHeader(403)
+					return
+				}
+				io.WriteString(w, "<title>"+r.PostForm.Get("q")+"</title>")
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				mu.Lock()
+				titles = append(titles, p.Text("title"))
+				mu.Unlock()
+
+				var reqs Requests
+				for _, f := range p.Forms() {
+					reqs = append(reqs, f.Set("q", "ant").Request())
+				}
+
+				return reqs, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{"index", "ant"}, titles)
+	})
+
+	t.Run("enqueue self", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var titles []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+
+			if r.Method == "POST" {
+				r.ParseForm()
+				io.WriteString(w, "<title>"+r.PostForm.Get("q")+"</title>")
+				return
+			}
+
+			io.WriteString(w, `
+				<title>search</title>
+				<form method="post">
+					<input name="q">
+				</form>
+			`)
+		}))
+		t.Cleanup(srv.Close)
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				mu.Lock()
+				titles = append(titles, p.Text("title"))
+				mu.Unlock()
+
+				var reqs Requests
+				for _, f := range p.Forms() {
+					reqs = append(reqs, f.Set("q", "ant").Request())
+				}
+
+				return reqs, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{"search", "ant"}, titles)
+	})
+}
@@ -15,6 +15,7 @@ require (
 	github.com/willf/bloom v2.0.3+incompatible
 	golang.org/x/net v0.41.0
 	golang.org/x/sync v0.15.0
+	golang.org/x/text v0.26.0
 	golang.org/x/time v0.12.0
 )
 
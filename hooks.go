@@ -0,0 +1,230 @@
+package ant
+
+import (
+	"context"
+	"time"
+)
+
+// HooksKey is the context key of the engine's hooks.
+type hooksKey struct{}
+
+// Discard enumerates the reasons a URL is discarded.
+type Discard string
+
+// All reasons a URL is discarded.
+//
+// URLs are discarded before they are queued, except
+// evicted URLs which a bounded queue dropped to make room.
+const (
+	DiscardDepth     Discard = "depth"
+	DiscardMatcher   Discard = "matcher"
+	DiscardDuplicate Discard = "duplicate"
+	DiscardHostPages Discard = "host_pages"
+	DiscardEvicted   Discard = "evicted"
+)
+
+// Hooks are called on engine lifecycle events.
+//
+// All hooks are optional, they are called synchronously from
+// the engine's goroutines and must be safe to use from multiple
+// goroutines, a slow hook slows down the crawl.
+type Hooks struct {
+	// OnEnqueue is called with URLs after they are queued.
+	//
+	// The hook is called with new URLs, URLs resumed
+	// from a checkpoint and URLs that are retried later.
+	OnEnqueue func(ctx context.Context, urls URLs)
+
+	// OnDequeue is called with a URL after it is dequeued.
+	OnDequeue func(ctx context.Context, url *URL)
+
+	// OnRobotsDisallowed is called with a URL
+	// that is disallowed by robots.txt.
+	OnRobotsDisallowed func(ctx context.Context, url *URL)
+
+	// OnFetchStart is called before a URL is fetched.
+	OnFetchStart func(ctx context.Context, url *URL)
+
+	// OnFetchDone is called once a URL is fetched and its body
+	// was read, before the page is scraped, with the response status
+	// code, the amount of body bytes and the duration since the
+	// fetch started.
+	//
+	// Bodies that exceed the fetcher's `MaxBufferSize` are streamed
+	// while they are scraped, the bytes include only the buffered
+	// part. The status is 0 if no response was received.
+	OnFetchDone func(ctx context.Context, url *URL, status int, bytes int64, dur time.Duration)
+
+	// OnPage is called with a fetched page before it is scraped.
+	//
+	// The hook must not read the page's body.
+	OnPage func(ctx context.Context, p *Page)
+
+	// OnRetry is called before a failed attempt is retried.
+	//
+	// The hook is called when the fetcher retries a request and
+	// when the engine retries a temporary scraper or deduper error,
+	// the URL is nil for deduper errors.
+	OnRetry func(ctx context.Context, url *URL, attempt int, err error)
+
+	// OnThrottle is called when a host is throttled.
+	//
+	// The hook is called when the fetcher receives a 429 or 503
+	// response, with the delay requests to the host wait for.
+	OnThrottle func(ctx context.Context, url *URL, delay time.Duration)
+
+	// OnScrape is called after a page is scraped with
+	// the URLs that the scraper returned and its error.
+	OnScrape func(ctx context.Context, url *URL, urls URLs, err error)
+
+	// OnDedupe is called with URLs before they are de-duplicated.
+	//
+	// The URLs that are duplicates are passed to OnDiscard
+	// with `DiscardDuplicate`.
+	OnDedupe func(ctx context.Context, urls URLs)
+
+	// OnDiscard is called with URLs that are discarded
+	// and the reason.
+	//
+	// URLs are discarded before they are queued, except URLs
+	// with `DiscardEvicted` which were queued and then evicted
+	// from a bounded queue.
+	OnDiscard func(ctx context.Context, urls URLs, reason Discard)
+
+	// OnDrop is called with queued URLs that are not visited
+	// because `Run()` returned early, e.g. when the engine was
+	// halted, drained or its context was canceled.
+	//
+	// The URLs remain pending and are included in a checkpoint.
+	OnDrop func(ctx context.Context, urls URLs)
+
+	// OnDone is called once a URL is handled with the error
+	// that occurred, before the error policy is applied.
+	OnDone func(ctx context.Context, url *URL, err error)
+}
+
+// Enqueue calls the OnEnqueue hook.
+func (h *Hooks) enqueue(ctx context.Context, urls URLs) {
+	if h.OnEnqueue != nil && len(urls) > 0 {
+		h.OnEnqueue(ctx, urls)
+	}
+}
+
+// Dequeue calls the OnDequeue hook.
+func (h *Hooks) dequeue(ctx context.Context, url *URL) {
+	if h.OnDequeue != nil {
+		h.OnDequeue(ctx, url)
+	}
+}
+
+// Disallowed calls the OnRobotsDisallowed hook.
+func (h *Hooks) disallowed(ctx context.Context, url *URL) {
+	if h.OnRobotsDisallowed != nil {
+		h.OnRobotsDisallowed(ctx, url)
+	}
+}
+
+// FetchStart calls the OnFetchStart hook.
+func (h *Hooks) fetchStart(ctx context.Context, url *URL) {
+	if h.OnFetchStart != nil {
+		h.OnFetchStart(ctx, url)
+	}
+}
+
+// FetchDone calls the OnFetchDone hook.
+func (h *Hooks) fetchDone(ctx context.Context, url *URL, status int, n int64, start time.Time) {
+	if h.OnFetchDone != nil {
+		h.OnFetchDone(ctx, url, status, n, time.Since(start))
+	}
+}
+
+// Page calls the OnPage hook.
+func (h *Hooks) page(ctx context.Context, p *Page) {
+	if h.OnPage != nil {
+		h.OnPage(ctx, p)
+	}
+}
+
+// Retry calls the OnRetry hook.
+func (h *Hooks) retry(ctx context.Context, url *URL, attempt int, err error) {
+	if h.OnRetry != nil {
+		h.OnRetry(ctx, url, attempt, err)
+	}
+}
+
+// Throttle calls the OnThrottle hook.
+func (h *Hooks) throttle(ctx context.Context, url *URL, delay time.Duration) {
+	if h.OnThrottle != nil {
+		h.OnThrottle(ctx, url, delay)
+	}
+}
+
+// Scrape calls the OnScrape hook.
+func (h *Hooks) scrape(ctx context.Context, url *URL, urls URLs, err error) {
+	if h.OnScrape != nil {
+		h.OnScrape(ctx, url, urls, err)
+	}
+}
+
+// Discard calls the OnDiscard hook with all URLs
+// in from that are not in to.
+func (h *Hooks) discard(ctx context.Context, from, to URLs, reason Discard) {
+	if h.OnDiscard == nil || len(from) == len(to) {
+		return
+	}
+
+	var kept = make(map[string]int, len(to))
+	var ret = make(URLs, 0, len(from)-len(to))
+
+	for _, u := range to {
+		kept[u.String()]++
+	}
+
+	for _, u := range from {
+		if k := u.String(); kept[k] > 0 {
+			kept[k]--
+		} else {
+			ret = append(ret, u)
+		}
+	}
+
+	if len(ret) > 0 {
+		h.OnDiscard(ctx, ret, reason)
+	}
+}
+
+// Dedupe calls the OnDedupe hook.
+func (h *Hooks) dedupe(ctx context.Context, urls URLs) {
+	if h.OnDedupe != nil && len(urls) > 0 {
+		h.OnDedupe(ctx, urls)
+	}
+}
+
+// Drop calls the OnDrop hook.
+func (h *Hooks) drop(ctx context.Context, urls URLs) {
+	if h.OnDrop != nil && len(urls) > 0 {
+		h.OnDrop(ctx, urls)
+	}
+}
+
+// Done calls the OnDone hook.
+func (h *Hooks) done(ctx context.Context, url *URL, err error) {
+	if h.OnDone != nil {
+		h.OnDone(ctx, url, err)
+	}
+}
+
+// WithHooks returns a new context with hooks h.
+func withHooks(ctx context.Context, h *Hooks) context.Context {
+	return context.WithValue(ctx, hooksKey{}, h)
+}
+
+// HooksFrom returns the hooks from ctx.
+//
+// If the context has no hooks, the method returns empty hooks.
+func hooksFrom(ctx context.Context) *Hooks {
+	if h, ok := ctx.Value(hooksKey{}).(*Hooks); ok {
+		return h
+	}
+	return &Hooks{}
+}
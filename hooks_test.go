@@ -0,0 +1,172 @@
+package ant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var events = &events{counts: make(map[string]int)}
+		var eng = setup(t, &visitor{})
+		var srv = server(t, "example.com")
+
+		eng.hooks = events.hooks()
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		assert.Equal(map[string]int{
+			"enqueue":           6,
+			"dequeue":           6,
+			"robots disallowed": 1,
+			"fetch start":       5,
+			"fetch done 200":    5,
+			"scrape":            5,
+			"discard duplicate": 2,
+			"done":              6,
+		}, events.counts)
+
+		sort.Strings(events.disallowed)
+		assert.Equal([]string{"/search.html"}, events.disallowed)
+		assert.True(events.bytes > 0)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var events = &events{counts: make(map[string]int)}
+		var eng = setup(t, &visitor{})
+		var srv = server(t, "example.com")
+
+		eng.hooks = events.hooks()
+		eng.matcher = MatchRegexp(`^127\.0\.0\.1:\d+/?(about\.html)?$`)
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		assert.Equal(2, events.counts["enqueue"])
+		assert.Equal(3, events.counts["discard matcher"])
+		assert.Equal(1, events.counts["discard duplicate"])
+	})
+
+	t.Run("discard depth", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var events = &events{counts: make(map[string]int)}
+		var eng = setup(t, &visitor{})
+		var srv = server(t, "example.com")
+
+		eng.hooks = events.hooks()
+		eng.maxDepth = 1
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		assert.Equal(4, events.counts["enqueue"])
+		assert.Equal(4, events.counts["discard depth"])
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var events = &events{counts: make(map[string]int)}
+		var eng = setup(t, &visitor{})
+		var srv = server(t, "example.com")
+
+		eng.hooks = events.hooks()
+		err := eng.Run(ctx, srv.URL+"/missing.html")
+		assert.NoError(err)
+
+		assert.Equal(1, events.counts["fetch done 404"])
+		assert.Equal(0, events.counts["scrape"])
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var events = &events{counts: make(map[string]int)}
+		var eng = setup(t, &visitor{})
+		var calls int64
+
+		var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt64(&calls, 1) == 1 {
+				w.WriteHeader(503)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		minBackoff = time.Nanosecond
+		maxBackoff = time.Millisecond
+
+		eng.hooks = events.hooks()
+		eng.impolite = true
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		assert.Equal(1, events.counts["retry"])
+		assert.Equal(1, events.counts["fetch done 200"])
+	})
+}
+
+// Events records engine hooks.
+type events struct {
+	mu         sync.Mutex
+	counts     map[string]int
+	disallowed []string
+	bytes      int64
+}
+
+// Add adds n events of kind.
+func (e *events) add(kind string, n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.counts[kind] += n
+}
+
+// Hooks returns hooks that record all events.
+func (e *events) hooks() Hooks {
+	return Hooks{
+		OnEnqueue: func(_ context.Context, urls URLs) {
+			e.add("enqueue", len(urls))
+		},
+		OnDequeue: func(context.Context, *URL) {
+			e.add("dequeue", 1)
+		},
+		OnRobotsDisallowed: func(_ context.Context, url *URL) {
+			e.add("robots disallowed", 1)
+			e.mu.Lock()
+			e.disallowed = append(e.disallowed, "/"+url.Path)
+			e.mu.Unlock()
+		},
+		OnFetchStart: func(context.Context, *URL) {
+			e.add("fetch start", 1)
+		},
+		OnFetchDone: func(_ context.Context, _ *URL, status int, n int64, _ time.Duration) {
+			e.add(fmt.Sprintf("fetch done %d", status), 1)
+			e.mu.Lock()
+			e.bytes += n
+			e.mu.Unlock()
+		},
+		OnRetry: func(context.Context, *URL, int, error) {
+			e.add("retry", 1)
+		},
+		OnScrape: func(context.Context, *URL, URLs, error) {
+			e.add("scrape", 1)
+		},
+		OnDiscard: func(_ context.Context, urls URLs, reason Discard) {
+			e.add("discard "+string(reason), len(urls))
+		},
+		OnDone: func(context.Context, *URL, error) {
+			e.add("done", 1)
+		},
+	}
+}
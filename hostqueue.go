@@ -0,0 +1,268 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Host represents a single host's queue.
+type host struct {
+	name string
+	urls URLs
+	next time.Time
+	last time.Time
+	busy bool
+}
+
+// Hostqueue implements a host partitioned queue.
+type hostqueue struct {
+	mu      sync.Mutex
+	hosts   map[string]*host
+	ring    []*host
+	cursor  int
+	delay   func(host string) time.Duration
+	crawl   func(ctx context.Context, u *URL) time.Duration
+	notify  chan struct{}
+	stopped bool
+	wg      *sync.WaitGroup
+	now     func() time.Time
+}
+
+// HostQueue returns a new host partitioned queue.
+//
+// The queue keeps a separate FIFO for each host and hands out URLs
+// by round-robin between the hosts, a URL of a host is handed out
+// only when at least `delay` has passed since the previous URL of
+// the same host was dequeued.
+//
+// This prevents a single link-heavy host from flooding the queue
+// and blocking all workers in limiters or robots.txt delays while
+// other hosts are idle.
+//
+// When delay <= 0, the queue round-robins between hosts without delay.
+func HostQueue(delay time.Duration) Queue {
+	return HostQueueFunc(func(string) time.Duration {
+		return delay
+	})
+}
+
+// HostQueueFunc returns a new host partitioned queue with per-host delays.
+//
+// The queue works like `HostQueue()`, the delay of each host is
+// the value of delay for the URL's host, including its port.
+//
+// When the queue is used by a polite engine, the engine applies
+// each host's robots.txt crawl delay in the queue instead of in
+// the workers, a host waits for the longer of the two delays.
+func HostQueueFunc(delay func(host string) time.Duration) Queue {
+	return &hostqueue{
+		hosts:  make(map[string]*host),
+		delay:  delay,
+		notify: make(chan struct{}),
+		wg:     &sync.WaitGroup{},
+		now:    time.Now,
+	}
+}
+
+// Enqueue implementation.
+func (hq *hostqueue) Enqueue(ctx context.Context, urls URLs) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	hq.mu.Lock()
+	defer hq.mu.Unlock()
+
+	if hq.stopped {
+		return io.EOF
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	for _, u := range urls {
+		h, ok := hq.hosts[u.Host]
+		if !ok {
+			h = &host{name: u.Host}
+			hq.hosts[u.Host] = h
+			hq.ring = append(hq.ring, h)
+		}
+		h.urls = append(h.urls, u)
+	}
+
+	hq.wg.Add(len(urls))
+	hq.broadcast()
+
+	return nil
+}
+
+// Dequeue implementation.
+//
+// The method blocks until a URL of any host is ready, until
+// the queue is closed or the context is canceled.
+func (hq *hostqueue) Dequeue(ctx context.Context) (*URL, error) {
+	for {
+		hq.mu.Lock()
+
+		if u, h, ok := hq.pop(); ok {
+			if hq.crawl == nil {
+				hq.mu.Unlock()
+				return u, nil
+			}
+
+			// The host is busy until its crawl delay
+			// is known, no other URL of the host is
+			// handed out in the meantime.
+			h.busy = true
+			hq.mu.Unlock()
+
+			d := hq.crawl(ctx, u)
+
+			hq.mu.Lock()
+			h.busy = false
+			if next := h.last.Add(d); next.After(h.next) {
+				h.next = next
+			}
+			hq.broadcast()
+			hq.mu.Unlock()
+
+			return u, nil
+		}
+
+		if hq.stopped {
+			hq.mu.Unlock()
+			return nil, io.EOF
+		}
+
+		if err := ctx.Err(); err != nil {
+			hq.mu.Unlock()
+			return nil, err
+		}
+
+		var notify = hq.notify
+		var wait, ok = hq.until()
+		var timer *time.Timer
+		var timeout <-chan time.Time
+
+		hq.mu.Unlock()
+
+		if ok {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-notify:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Pop pops the next ready URL.
+//
+// The method walks the hosts starting at the cursor, removes
+// hosts that have no URLs and whose delay has passed and returns
+// the first URL of a ready host along with the host.
+func (hq *hostqueue) pop() (*URL, *host, bool) {
+	var now = hq.now()
+
+	for n := len(hq.ring); n > 0; n-- {
+		if hq.cursor >= len(hq.ring) {
+			hq.cursor = 0
+		}
+
+		h := hq.ring[hq.cursor]
+
+		if h.busy || now.Before(h.next) {
+			hq.cursor++
+			continue
+		}
+
+		if len(h.urls) == 0 {
+			hq.remove(hq.cursor)
+			continue
+		}
+
+		u := h.urls[0]
+		h.urls = h.urls[1:]
+		h.next = now.Add(hq.delay(h.name))
+		h.last = now
+		hq.cursor++
+
+		return u, h, true
+	}
+
+	return nil, nil, false
+}
+
+// Until returns the duration until the next host is ready.
+//
+// The method returns false if there are no queued URLs, busy
+// hosts are skipped, they wake up dequeues once they are ready.
+func (hq *hostqueue) until() (time.Duration, bool) {
+	var now = hq.now()
+	var min time.Duration
+	var ok bool
+
+	for _, h := range hq.ring {
+		if h.busy || len(h.urls) == 0 {
+			continue
+		}
+		if d := h.next.Sub(now); !ok || d < min {
+			min, ok = d, true
+		}
+	}
+
+	return min, ok
+}
+
+// Remove removes the host at index j.
+func (hq *hostqueue) remove(j int) {
+	delete(hq.hosts, hq.ring[j].name)
+	copy(hq.ring[j:], hq.ring[j+1:])
+	hq.ring[len(hq.ring)-1] = nil
+	hq.ring = hq.ring[:len(hq.ring)-1]
+}
+
+// Broadcast wakes up all waiting dequeues.
+func (hq *hostqueue) broadcast() {
+	close(hq.notify)
+	hq.notify = make(chan struct{})
+}
+
+// Done implementation.
+func (hq *hostqueue) Done(_ context.Context, _ *URL) error {
+	hq.wg.Done()
+	return nil
+}
+
+// Wait implementation.
+func (hq *hostqueue) Wait() {
+	hq.wg.Wait()
+}
+
+// Close implementation.
+func (hq *hostqueue) Close(_ context.Context) error {
+	hq.mu.Lock()
+	defer hq.mu.Unlock()
+
+	for _, h := range hq.ring {
+		for range h.urls {
+			hq.wg.Done()
+		}
+	}
+
+	hq.stopped = true
+	hq.hosts = make(map[string]*host)
+	hq.ring = nil
+	hq.broadcast()
+	return nil
+}
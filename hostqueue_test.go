@@ -0,0 +1,150 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostqueue(t *testing.T) {
+	t.Run("round robin", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueue(0)
+		var recv []string
+
+		urls := parseURLs(t,
+			"https://a/1",
+			"https://a/2",
+			"https://a/3",
+			"https://b/1",
+			"https://c/1",
+		)
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		for range urls {
+			u, err := q.Dequeue(ctx)
+			assert.NoError(err)
+			recv = append(recv, u.String())
+		}
+
+		expect := []string{
+			"https://a/1",
+			"https://b/1",
+			"https://c/1",
+			"https://a/2",
+			"https://a/3",
+		}
+
+		assert.Equal(expect, recv)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueue(50 * time.Millisecond)
+
+		urls := parseURLs(t, "https://a/1", "https://a/2", "https://b/1")
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		start := time.Now()
+		for _, expect := range []string{"https://a/1", "https://b/1", "https://a/2"} {
+			u, err := q.Dequeue(ctx)
+			assert.NoError(err)
+			assert.Equal(expect, u.String())
+		}
+
+		assert.True(time.Since(start) >= 50*time.Millisecond)
+	})
+
+	t.Run("delay per host", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueueFunc(func(host string) time.Duration {
+			if host == "b" {
+				return time.Hour
+			}
+			return 0
+		})
+
+		urls := parseURLs(t, "https://a/1", "https://a/2", "https://b/1", "https://b/2")
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		for _, expect := range []string{"https://a/1", "https://b/1", "https://a/2"} {
+			u, err := q.Dequeue(ctx)
+			assert.NoError(err)
+			assert.Equal(expect, u.String())
+		}
+
+		subctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := q.Dequeue(subctx)
+		assert.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("crawl delay", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueue(0).(*hostqueue)
+
+		q.crawl = func(_ context.Context, u *URL) time.Duration {
+			if u.Host == "a" {
+				return time.Hour
+			}
+			return 0
+		}
+
+		urls := parseURLs(t, "https://a/1", "https://a/2", "https://b/1", "https://b/2")
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		for _, expect := range []string{"https://a/1", "https://b/1", "https://b/2"} {
+			u, err := q.Dequeue(ctx)
+			assert.NoError(err)
+			assert.Equal(expect, u.String())
+		}
+
+		subctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := q.Dequeue(subctx)
+		assert.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("delay canceled context", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueue(time.Hour)
+
+		urls := parseURLs(t, "https://a/1", "https://a/2")
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		_, err := q.Dequeue(ctx)
+		assert.NoError(err)
+
+		subctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err = q.Dequeue(subctx)
+		assert.Equal(context.DeadlineExceeded, err)
+	})
+
+	t.Run("close wakes dequeue", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = HostQueue(0)
+		var errc = make(chan error)
+
+		go func() {
+			_, err := q.Dequeue(ctx)
+			errc <- err
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+		assert.NoError(q.Close(ctx))
+		assert.Equal(io.EOF, <-errc)
+	})
+}
@@ -7,6 +7,7 @@ package robots
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -56,6 +57,14 @@ func (h *Host) test(path, ua string) bool {
 	return true
 }
 
+// Sitemaps returns the host's sitemap URLs.
+func (h *Host) sitemaps() []string {
+	if h.data != nil {
+		return h.data.Sitemaps
+	}
+	return nil
+}
+
 // Cache implements an LRU robots cache.
 //
 // The cache maintains an LRU of domain names
@@ -63,6 +72,14 @@ func (h *Host) test(path, ua string) bool {
 // domain is seen the cache will fetch the robots.txt
 // parse it, and add it to the cache.
 type Cache struct {
+	// Logger is the logger to use.
+	//
+	// The cache logs fetched robots.txt files and
+	// crawl delays at debug level.
+	//
+	// If nil, the cache does not log.
+	Logger *slog.Logger
+
 	lru    *agecache.Cache
 	client *http.Client
 }
@@ -112,29 +129,59 @@ func (c *Cache) Allowed(ctx context.Context, req Request) (bool, error) {
 // Some robots.txt define a crawl delay for all or some of the useragents.
 // The method will block until the request can go through.
 func (c *Cache) Wait(ctx context.Context, req Request) error {
-	var ua = req.userAgent()
-
-	host, err := c.lookup(ctx, req.URL)
+	d, err := c.Delay(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	if g, ok := host.find(ua); ok {
-		if d := g.CrawlDelay; d > 0 {
-			t := time.NewTimer(d)
-			defer t.Stop()
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-t.C:
-				return nil
-			}
+	if d > 0 {
+		c.logger().DebugContext(ctx, "robots: crawl delay",
+			"url", req.URL.String(),
+			"host", req.URL.Host,
+			"duration", d,
+		)
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			return nil
 		}
 	}
 
 	return nil
 }
 
+// Delay returns the crawl delay of the request's host.
+//
+// The method returns zero if the robots.txt does
+// not define a crawl delay for the useragent.
+func (c *Cache) Delay(ctx context.Context, req Request) (time.Duration, error) {
+	host, err := c.lookup(ctx, req.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	if g, ok := host.find(req.userAgent()); ok {
+		return g.CrawlDelay, nil
+	}
+
+	return 0, nil
+}
+
+// Sitemaps returns the sitemap URLs listed in the robots.txt of url's host.
+//
+// The method returns an empty list if the robots.txt does
+// not list any sitemaps or if it does not exist.
+func (c *Cache) Sitemaps(ctx context.Context, url *url.URL) ([]string, error) {
+	host, err := c.lookup(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return host.sitemaps(), nil
+}
+
 // Lookup returns a host from url.
 //
 // Note that there's a logical race, the method may send multiple requests
@@ -156,6 +203,12 @@ func (c *Cache) lookup(ctx context.Context, url *url.URL) (*Host, error) {
 	}
 	defer resp.Body.Close()
 
+	c.logger().DebugContext(ctx, "robots: fetched robots.txt",
+		"url", rawurl,
+		"host", url.Host,
+		"status", resp.StatusCode,
+	)
+
 	if resp.StatusCode >= 400 {
 		s := &Host{}
 		c.lru.Set(url.Host, s)
@@ -171,3 +224,11 @@ func (c *Cache) lookup(ctx context.Context, url *url.URL) (*Host, error) {
 	c.lru.Set(url.Host, s)
 	return s, nil
 }
+
+// Logger returns the logger to use.
+func (c *Cache) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
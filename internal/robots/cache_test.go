@@ -3,10 +3,13 @@ package robots
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -66,6 +69,21 @@ func TestCache(t *testing.T) {
 		assert.NoError(err)
 	})
 
+	t.Run("delay duration", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var cache = NewCache(http.DefaultClient, 50)
+		var url = serve(t, "testdata/robots.txt")
+
+		d, err := cache.Delay(ctx, request(t, url, "badbot"))
+		assert.NoError(err)
+		assert.Equal(time.Second, d)
+
+		d, err = cache.Delay(ctx, request(t, url, "goodbot"))
+		assert.NoError(err)
+		assert.Zero(d)
+	})
+
 	t.Run("delay cancel", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -84,6 +102,40 @@ func TestCache(t *testing.T) {
 		assert.True(errors.Is(err, context.Canceled))
 	})
 
+	t.Run("sitemaps", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var cache = NewCache(http.DefaultClient, 50)
+		var url = serve(t, "testdata/robots.txt")
+
+		sitemaps, err := cache.Sitemaps(ctx, request(t, url, "ant").URL)
+		assert.NoError(err)
+		assert.Equal([]string{
+			"https://example.com/sitemap.xml",
+			"https://example.com/news.xml.gz",
+		}, sitemaps)
+
+		sitemaps, err = cache.Sitemaps(ctx, request(t, serve(t, "testdata/404.txt"), "ant").URL)
+		assert.NoError(err)
+		assert.Empty(sitemaps)
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var cache = NewCache(http.DefaultClient, 50)
+		var url = serve(t, "testdata/robots.txt")
+		var buf strings.Builder
+
+		cache.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))
+
+		_, err := cache.Allowed(ctx, request(t, url+"/foo", "ant"))
+		assert.NoError(err)
+		assert.Contains(buf.String(), `msg="robots: fetched robots.txt"`)
+	})
+
 	t.Run("when robots.txt 404s, all URLs are allowed", func(t *testing.T) {
 		var ctx = context.Background()
 		var assert = require.New(t)
@@ -0,0 +1,128 @@
+package ant
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Middleware represents a client middleware.
+//
+// A middleware wraps a client to intercept requests and responses,
+// it must not modify the request it receives, instead it should
+// clone it, see `Fetcher.Middleware`.
+type Middleware func(Client) Client
+
+// ClientFunc implements a client.
+type ClientFunc func(*http.Request) (*http.Response, error)
+
+// Do implementation.
+func (f ClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain returns c wrapped with all middleware.
+//
+// The first middleware is the outermost, it
+// receives requests first and responses last.
+func chain(c Client, mw []Middleware) Client {
+	for j := len(mw) - 1; j >= 0; j-- {
+		c = mw[j](c)
+	}
+	return c
+}
+
+// StaticHeaders returns a middleware that sets headers h.
+//
+// The headers replace the headers that are
+// already set on the request, e.g. its Accept header.
+func StaticHeaders(h http.Header) Middleware {
+	return Sign(func(req *http.Request) error {
+		for k, v := range h {
+			req.Header[http.CanonicalHeaderKey(k)] = v
+		}
+		return nil
+	})
+}
+
+// BasicAuth returns a middleware that sets basic auth
+// credentials on requests to host.
+//
+// The host is matched against the request's host, with
+// and without its port.
+func BasicAuth(host, username, password string) Middleware {
+	return Sign(func(req *http.Request) error {
+		if matchHost(req, host) {
+			req.SetBasicAuth(username, password)
+		}
+		return nil
+	})
+}
+
+// BearerAuth returns a middleware that sets
+// a bearer token on requests to host.
+//
+// The host is matched against the request's host, with
+// and without its port.
+func BearerAuth(host, token string) Middleware {
+	return Sign(func(req *http.Request) error {
+		if matchHost(req, host) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return nil
+	})
+}
+
+// Sign returns a middleware that calls sign with every request.
+//
+// The function receives a clone of the request and may modify
+// it, e.g. to sign it or rewrite its URL, when it returns an
+// error the request is not sent.
+//
+// The function is called on every attempt, the
+// request body must not be read.
+func Sign(sign func(*http.Request) error) Middleware {
+	return func(next Client) Client {
+		return ClientFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			if err := sign(req); err != nil {
+				return nil, fmt.Errorf("ant: sign %q - %w", req.URL, err)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// LogResponses returns a middleware that logs
+// every response to l at info level.
+func LogResponses(l *slog.Logger) Middleware {
+	return func(next Client) Client {
+		return ClientFunc(func(req *http.Request) (*http.Response, error) {
+			var start = time.Now()
+			var resp, err = next.Do(req)
+			var attrs = []any{
+				"method", req.Method,
+				"url", req.URL.String(),
+				"host", req.URL.Host,
+				"duration", time.Since(start),
+			}
+
+			if resp != nil {
+				attrs = append(attrs, "status", resp.StatusCode)
+			}
+
+			if err != nil {
+				attrs = append(attrs, "error", err)
+			}
+
+			l.InfoContext(req.Context(), "ant: response", attrs...)
+			return resp, err
+		})
+	}
+}
+
+// MatchHost returns true if host matches the request's host.
+func matchHost(req *http.Request, host string) bool {
+	return req.URL.Host == host || req.URL.Hostname() == host
+}
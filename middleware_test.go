@@ -0,0 +1,162 @@
+package ant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("chain", func(t *testing.T) {
+		var assert = require.New(t)
+		var calls []string
+
+		mw := func(name string) Middleware {
+			return func(next Client) Client {
+				return ClientFunc(func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, name)
+					return next.Do(req)
+				})
+			}
+		}
+
+		c := chain(ClientFunc(func(req *http.Request) (*http.Response, error) {
+			calls = append(calls, "client")
+			return &http.Response{StatusCode: 200}, nil
+		}), []Middleware{mw("a"), mw("b")})
+
+		_, err := c.Do(newRequest(t, "https://example.com"))
+		assert.NoError(err)
+		assert.Equal([]string{"a", "b", "client"}, calls)
+	})
+
+	t.Run("chain once", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var req http.Request
+		var url = record(t, &req)
+		var wraps int
+
+		fetcher := &Fetcher{
+			Middleware: []Middleware{
+				func(next Client) Client {
+					wraps++
+					return next
+				},
+			},
+		}
+
+		for j := 0; j < 3; j++ {
+			_, err := fetcher.Fetch(ctx, url)
+			assert.NoError(err)
+		}
+
+		assert.Equal(1, wraps)
+	})
+
+	t.Run("headers and auth", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var req http.Request
+		var url = record(t, &req)
+
+		fetcher := &Fetcher{
+			Middleware: []Middleware{
+				StaticHeaders(http.Header{"accept": {"application/json"}, "X-Foo": {"bar"}}),
+				BasicAuth("example.com", "user", "pass"),
+				BearerAuth(url.Hostname(), "token"),
+			},
+		}
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+
+		assert.Equal("application/json", req.Header.Get("Accept"))
+		assert.Equal("bar", req.Header.Get("X-Foo"))
+		assert.Equal("Bearer token", req.Header.Get("Authorization"))
+	})
+
+	t.Run("basic auth", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var req http.Request
+		var url = record(t, &req)
+
+		fetcher := &Fetcher{
+			Middleware: []Middleware{
+				BasicAuth(url.Host, "user", "pass"),
+			},
+		}
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+
+		user, pass, ok := req.BasicAuth()
+		assert.True(ok)
+		assert.Equal("user", user)
+		assert.Equal("pass", pass)
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		var assert = require.New(t)
+		var orig = newRequest(t, "https://example.com")
+		var sent *http.Request
+
+		c := Sign(func(req *http.Request) error {
+			req.Header.Set("X-Signature", req.Method+" "+req.URL.Path)
+			return nil
+		})(ClientFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			return &http.Response{StatusCode: 200}, nil
+		}))
+
+		_, err := c.Do(orig)
+		assert.NoError(err)
+		assert.Equal("GET ", sent.Header.Get("X-Signature"))
+		assert.Equal("", orig.Header.Get("X-Signature"))
+
+		c = Sign(func(req *http.Request) error {
+			return errors.New("no key")
+		})(c)
+
+		_, err = c.Do(orig)
+		assert.EqualError(err, `ant: sign "https://example.com" - no key`)
+	})
+
+	t.Run("log responses", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var buf bytes.Buffer
+		var url = serve(t, respond(200, ""))
+
+		fetcher := &Fetcher{
+			Middleware: []Middleware{
+				LogResponses(slog.New(slog.NewTextHandler(&buf, nil))),
+			},
+		}
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+
+		assert.Contains(buf.String(), "msg=\"ant: response\" method=GET")
+		assert.Contains(buf.String(), "status=200")
+		assert.Equal(1, strings.Count(buf.String(), "\n"))
+	})
+}
+
+func newRequest(t testing.TB, rawurl string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		t.Fatalf("new request: %s", err)
+	}
+
+	return req
+}
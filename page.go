@@ -1,33 +1,193 @@
 package ant
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/yields/ant/internal/scan"
 	"github.com/yields/ant/internal/selectors"
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
 )
 
 // Page represents a page.
 type Page struct {
 	URL    *url.URL
 	Header http.Header
-	body   io.ReadCloser
-	root   *html.Node
-	once   sync.Once
-	err    error
+
+	// StatusCode is the response status code.
+	StatusCode int
+
+	// RequestURL is the URL that was requested.
+	//
+	// It differs from URL when the request was redirected.
+	RequestURL *url.URL
+
+	// Redirects is the redirect chain in the order the
+	// redirects were followed, it is empty if the request
+	// was not redirected.
+	Redirects []Redirect
+
+	// FetchedAt is the time the page was fetched at.
+	FetchedAt time.Time
+
+	// Duration is the time it took to receive the response
+	// headers, including redirects, of the last attempt.
+	Duration time.Duration
+
+	// ContentLength is the response content length.
+	//
+	// The value -1 indicates that the length is unknown.
+	ContentLength int64
+
+	// Proto is the response protocol, e.g. "HTTP/1.1".
+	Proto string
+
+	// TLS contains the TLS connection details, it is
+	// nil if the page was fetched without TLS.
+	TLS *tls.ConnectionState
+
+	// Depth is the amount of hops from the initial URLs.
+	//
+	// It is set by the engine, pages that are fetched
+	// directly have a depth of 0.
+	Depth int
+
+	// Referrer is the URL of the page that linked to the page.
+	//
+	// It is set by the engine, it is nil for the initial
+	// URLs and pages that are fetched directly.
+	Referrer *url.URL
+
+	// Meta is the user metadata of the request
+	// that fetched the page, see `Request.Meta`.
+	Meta map[string]any
+
+	body     io.ReadCloser
+	guard    *body
+	limit    int64
+	buf      []byte
+	rest     io.Reader
+	loadOnce sync.Once
+	loadErr  error
+	root     *html.Node
+	charset  string
+	once     sync.Once
+	err      error
+}
+
+// ErrBufferLimit is returned when the page's body
+// exceeds the fetcher's `MaxBufferSize`.
+var ErrBufferLimit = errors.New("ant: page body exceeds buffer limit")
+
+// DefaultBufferSize is the default buffer limit.
+const defaultBufferSize = 10 << 20
+
+// Redirect represents a redirect response.
+type Redirect struct {
+	// URL is the URL that responded with the redirect.
+	URL *url.URL
+
+	// StatusCode is the redirect status code.
+	StatusCode int
 }
 
 // Body returns the raw body of the page.
 //
-// Note: if the body is read, the page's methods will not be available.
+// The body is buffered, every call returns a new reader and
+// the page's methods remain available after it is read. If the
+// body exceeds the buffer limit it is streamed instead and can
+// only be read once, by the caller or by the page's methods.
 func (p *Page) Body() io.Reader {
-	return p.body
+	if err := p.load(); err != nil {
+		return p.rest
+	}
+	return bytes.NewReader(p.buf)
+}
+
+// Truncated returns true if the page's body was truncated.
+//
+// Bodies are truncated when the fetcher's `TruncateBody` is
+// true and they exceed its `MaxBodySize`, the method returns
+// true once the truncated body was read.
+func (p *Page) Truncated() bool {
+	return p.guard != nil && p.guard.truncated.Load()
+}
+
+// Bytes returns the raw body of the page.
+//
+// The method returns `ErrBufferLimit` if the body
+// exceeds the buffer limit.
+func (p *Page) Bytes() ([]byte, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p.buf, nil
+}
+
+// Hash returns the hex encoded SHA-256 hash of the page's body.
+//
+// The hash can be compared with the hash of a previous fetch
+// to detect changes, the method returns `ErrBufferLimit` if the
+// body exceeds the buffer limit.
+func (p *Page) Hash() (string, error) {
+	buf, err := p.Bytes()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ContentType returns the page's media type.
+//
+// The media type is parsed from the Content-Type header and
+// is lower case without parameters, e.g. "application/json",
+// the method returns an empty string if the header is missing
+// or invalid.
+func (p *Page) ContentType() string {
+	mt, _, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// JSON decodes the page's JSON body into dst.
+func (p *Page) JSON(dst any) error {
+	if err := json.NewDecoder(p.Body()).Decode(dst); err != nil {
+		return fmt.Errorf("ant: decode json %q - %w", p.URL, err)
+	}
+	return nil
+}
+
+// XML decodes the page's XML body into dst.
+//
+// Documents that declare an encoding other
+// than UTF-8 are transcoded.
+func (p *Page) XML(dst any) error {
+	dec := xml.NewDecoder(p.Body())
+	dec.CharsetReader = charset.NewReaderLabel
+
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("ant: decode xml %q - %w", p.URL, err)
+	}
+
+	return nil
 }
 
 // Document returns the parsed document.
@@ -60,14 +220,66 @@ func (p *Page) HTML() (string, error) {
 // errored, the method is a no-op.
 func (p *Page) parse() error {
 	p.once.Do(func() {
-		if p.root, p.err = html.Parse(p.body); p.err != nil {
+		if p.root, p.err = html.Parse(p.decode(p.Body())); p.err != nil {
 			p.err = fmt.Errorf("ant: parse html %q - %w", p.URL, p.err)
 		}
-		p.close()
 	})
 	return p.err
 }
 
+// Charset returns the name of the page's character encoding.
+//
+// The encoding is detected from the BOM, the Content-Type header
+// and the HTML5 `<meta>` prescan, in that order, the page's
+// methods transcode the body to UTF-8 before it is parsed.
+func (p *Page) Charset() string {
+	p.parse()
+	return p.charset
+}
+
+// Decode returns a reader that transcodes r to UTF-8.
+func (p *Page) decode(r io.Reader) io.Reader {
+	var br = bufio.NewReaderSize(r, 1024)
+	var head, _ = br.Peek(1024)
+	var e, name, _ = charset.DetermineEncoding(head, p.Header.Get("Content-Type"))
+
+	if p.charset = name; name == "utf-8" {
+		return br
+	}
+
+	return transform.NewReader(br, e.NewDecoder())
+}
+
+// Load buffers the page's body.
+//
+// The body is read once, up to the limit, if the body exceeds
+// the limit or a read error occurs the read bytes are kept
+// in front of the remaining body.
+func (p *Page) load() error {
+	p.loadOnce.Do(func() {
+		var limit = p.limit
+
+		if limit <= 0 {
+			limit = defaultBufferSize
+		}
+
+		buf, err := io.ReadAll(io.LimitReader(p.body, limit+1))
+		switch {
+		case err != nil:
+			p.loadErr = fmt.Errorf("ant: read body %q - %w", p.URL, err)
+		case int64(len(buf)) > limit:
+			p.loadErr = ErrBufferLimit
+		default:
+			p.buf = buf
+			p.body.Close()
+			return
+		}
+
+		p.rest = io.MultiReader(bytes.NewReader(buf), p.body)
+	})
+	return p.loadErr
+}
+
 // Query returns all nodes matching selector.
 //
 // The method returns an empty list if no nodes were found.
@@ -2,11 +2,16 @@ package ant
 
 import (
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func TestPage(t *testing.T) {
@@ -21,6 +26,145 @@ func TestPage(t *testing.T) {
 		assert.Equal("<title>foo</title>", string(content))
 	})
 
+	t.Run("bytes", func(t *testing.T) {
+		var page = makePage(t, `<title>foo</title>`)
+		var assert = require.New(t)
+
+		buf, err := page.Bytes()
+		assert.NoError(err)
+		assert.Equal("<title>foo</title>", string(buf))
+
+		content, err := io.ReadAll(page.Body())
+		assert.NoError(err)
+		assert.Equal("<title>foo</title>", string(content))
+
+		assert.Equal("foo", page.Text("title"))
+
+		content, err = io.ReadAll(page.Body())
+		assert.NoError(err)
+		assert.Equal("<title>foo</title>", string(content))
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		var assert = require.New(t)
+
+		a, err := makePage(t, `<title>foo</title>`).Hash()
+		assert.NoError(err)
+		assert.Len(a, 64)
+
+		b, err := makePage(t, `<title>foo</title>`).Hash()
+		assert.NoError(err)
+		assert.Equal(a, b)
+
+		c, err := makePage(t, `<title>bar</title>`).Hash()
+		assert.NoError(err)
+		assert.NotEqual(a, c)
+	})
+
+	t.Run("buffer limit", func(t *testing.T) {
+		var page = makePage(t, `<title>foo</title>`)
+		var assert = require.New(t)
+
+		page.limit = 5
+
+		_, err := page.Bytes()
+		assert.Equal(ErrBufferLimit, err)
+
+		_, err = page.Hash()
+		assert.Equal(ErrBufferLimit, err)
+
+		assert.Equal("foo", page.Text("title"))
+	})
+
+	t.Run("charset", func(t *testing.T) {
+		var cases = []struct {
+			title   string
+			header  string
+			meta    string
+			enc     encoding.Encoding
+			charset string
+		}{
+			{"Привет", "text/html; charset=windows-1251", "", charmap.Windows1251, "windows-1251"},
+			{"こんにちは", "text/html", `<meta charset="shift_jis">`, japanese.ShiftJIS, "shift_jis"},
+			{"café", "", `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">`, charmap.ISO8859_1, "windows-1252"},
+			{"café", "", "", nil, "utf-8"},
+		}
+
+		for _, c := range cases {
+			var assert = require.New(t)
+			var raw = c.meta + "<title>" + c.title + "</title>"
+
+			if c.enc != nil {
+				s, err := c.enc.NewEncoder().String(raw)
+				assert.NoError(err)
+				raw = s
+			}
+
+			page := makePage(t, raw)
+			page.Header = http.Header{"Content-Type": {c.header}}
+
+			assert.Equal(c.title, page.Text("title"))
+			assert.Equal(c.charset, page.Charset())
+
+			buf, err := page.Bytes()
+			assert.NoError(err)
+			assert.Equal(raw, string(buf))
+		}
+	})
+
+	t.Run("charset bom", func(t *testing.T) {
+		var assert = require.New(t)
+
+		raw, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("<title>héllo</title>")
+		assert.NoError(err)
+
+		page := makePage(t, raw)
+		page.Header = http.Header{"Content-Type": {"text/html; charset=iso-8859-1"}}
+
+		assert.Equal("héllo", page.Text("title"))
+		assert.Equal("utf-16le", page.Charset())
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		var page = makePage(t, ``)
+		var assert = require.New(t)
+
+		assert.Equal("", page.ContentType())
+
+		page.Header = http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}}
+		assert.Equal("application/json", page.ContentType())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var page = makePage(t, `{"name":"ant","stars":9}`)
+		var assert = require.New(t)
+		var repo struct {
+			Name  string `json:"name"`
+			Stars int    `json:"stars"`
+		}
+
+		assert.NoError(page.JSON(&repo))
+		assert.Equal("ant", repo.Name)
+		assert.Equal(9, repo.Stars)
+
+		err := makePage(t, `{`).JSON(&repo)
+		assert.EqualError(err, `ant: decode json "https://example.com" - unexpected EOF`)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		var assert = require.New(t)
+		var feed struct {
+			Items []string `xml:"channel>item>title"`
+		}
+
+		raw, err := charmap.ISO8859_1.NewEncoder().String(`<?xml version="1.0" encoding="ISO-8859-1"?>` +
+			`<rss><channel><item><title>café</title></item><item><title>b</title></item></channel></rss>`)
+		assert.NoError(err)
+
+		assert.NoError(makePage(t, raw).XML(&feed))
+		assert.Equal([]string{"café", "b"}, feed.Items)
+	})
+
 	t.Run("html", func(t *testing.T) {
 		var page = makePage(t, `<title>foo</title>`)
 		var assert = require.New(t)
@@ -0,0 +1,105 @@
+package ant
+
+import (
+	"context"
+	"sync"
+)
+
+// Gate blocks workers while the engine is paused.
+type gate struct {
+	mu     sync.Mutex
+	paused chan struct{}
+}
+
+// Close closes the gate.
+func (g *gate) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused == nil {
+		g.paused = make(chan struct{})
+	}
+}
+
+// Open opens the gate and wakes all waiting workers.
+func (g *gate) open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused != nil {
+		close(g.paused)
+		g.paused = nil
+	}
+}
+
+// Wait blocks until the gate is opened.
+//
+// The method returns early when stop is closed, it
+// returns the context's error if it is canceled.
+func (g *gate) wait(ctx context.Context, stop <-chan struct{}) error {
+	g.mu.Lock()
+	paused := g.paused
+	g.mu.Unlock()
+
+	if paused == nil {
+		return nil
+	}
+
+	select {
+	case <-paused:
+		return nil
+	case <-stop:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause pauses the engine.
+//
+// The workers stop dequeuing URLs until `Resume()` is called,
+// pages that are in-flight are processed and the URLs they
+// discover are queued, `Run()` does not return while the engine
+// is paused unless its context is canceled or it is drained, even
+// when all queued URLs were handled.
+//
+// Calling the method on a paused engine is a no-op.
+func (eng *Engine) Pause() {
+	eng.gate.close()
+	eng.logger.Info("ant: pause")
+}
+
+// Resume resumes a paused engine.
+//
+// Calling the method on an engine that is not paused is a no-op.
+func (eng *Engine) Resume() {
+	eng.gate.open()
+	eng.logger.Info("ant: resume")
+}
+
+// Drain gracefully stops the engine.
+//
+// The engine stops accepting new URLs and closes its queue,
+// the workers finish all in-flight pages and `Run()` returns
+// without an error. Queued URLs that were not visited remain
+// pending and can be resumed with `Engine.Checkpoint()`.
+//
+// The method blocks until `Run()` returns or the context is
+// canceled, it can be called on a paused engine.
+//
+// If the engine is not running, e.g. when `Run()` was not
+// called yet or already returned, the method is a no-op.
+func (eng *Engine) Drain(ctx context.Context) error {
+	if !eng.running.Load() {
+		return nil
+	}
+
+	eng.halt(nil)
+
+	select {
+	case <-eng.finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,167 @@
+package ant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPause(t *testing.T) {
+	t.Run("pause and resume", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng *Engine
+		var srv = server(t, "example.com")
+		var scraped int64
+
+		eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			if atomic.AddInt64(&scraped, 1) == 1 {
+				eng.Pause()
+			}
+			return visitor.Scrape(ctx, p)
+		}))
+
+		var errc = make(chan error, 1)
+		go func() { errc <- eng.Run(ctx, srv.URL) }()
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(int64(1), atomic.LoadInt64(&scraped))
+
+		eng.Resume()
+		assert.NoError(<-errc)
+
+		sort.Strings(visitor.paths)
+		assert.Equal([]string{
+			"/",
+			"/a.html",
+			"/about.html",
+			"/b.html",
+			"/products.html",
+		}, visitor.paths)
+	})
+
+	t.Run("run waits while paused", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng *Engine
+		var srv = server(t, "example.com")
+
+		eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			visitor.Scrape(ctx, p)
+			eng.Pause()
+			return nil, nil
+		}))
+
+		var errc = make(chan error, 1)
+		go func() { errc <- eng.Run(ctx, srv.URL) }()
+
+		select {
+		case err := <-errc:
+			t.Fatalf("run returned while paused: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		eng.Resume()
+		assert.NoError(<-errc)
+		assert.Equal([]string{"/"}, visitor.paths)
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var srv = server(t, "example.com")
+		var started = make(chan struct{})
+		var release = make(chan struct{})
+
+		var eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			close(started)
+			<-release
+			return visitor.Scrape(ctx, p)
+		}))
+
+		var errc = make(chan error, 1)
+		go func() { errc <- eng.Run(ctx, srv.URL) }()
+
+		<-started
+		var drained = make(chan error, 1)
+		go func() { drained <- eng.Drain(ctx) }()
+
+		<-eng.stop
+		close(release)
+
+		assert.NoError(<-drained)
+		assert.NoError(<-errc)
+		assert.Equal([]string{"/"}, visitor.paths)
+
+		var buf bytes.Buffer
+		var cp checkpoint
+		assert.NoError(eng.Checkpoint(&buf))
+		assert.NoError(json.Unmarshal(buf.Bytes(), &cp))
+		assert.Equal(3, len(cp.Queued))
+		assert.Equal(0, len(cp.Inflight))
+	})
+
+	t.Run("drain paused", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		eng.Pause()
+
+		var errc = make(chan error, 1)
+		go func() { errc <- eng.Run(ctx, srv.URL) }()
+
+		assert.Eventually(eng.running.Load, time.Second, time.Millisecond)
+		assert.NoError(eng.Drain(ctx))
+		assert.NoError(<-errc)
+		assert.Empty(visitor.paths)
+	})
+
+	t.Run("drain before run", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, visitor)
+		var srv = server(t, "example.com")
+
+		assert.NoError(eng.Drain(ctx))
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.NotEmpty(visitor.paths)
+	})
+
+	t.Run("drain canceled context", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var started = make(chan struct{})
+		var release = make(chan struct{})
+
+		var eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			close(started)
+			<-release
+			return nil, nil
+		}))
+
+		var errc = make(chan error, 1)
+		go func() { errc <- eng.Run(ctx, srv.URL) }()
+		<-started
+
+		subctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		assert.ErrorIs(eng.Drain(subctx), context.Canceled)
+		close(release)
+		assert.NoError(<-errc)
+	})
+}
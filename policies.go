@@ -0,0 +1,149 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrRetry is returned by an error policy to retry a URL later.
+//
+// When a policy returns the error, the engine queues the URL
+// again without de-duplicating it.
+var ErrRetry = errors.New("ant: retry")
+
+// ErrorPolicy decides how the engine handles errors.
+//
+// The policy is called with the URL and the error that occurred
+// while the URL was processed, fetch, robots.txt, limiter and scraper
+// errors all go through the policy, context cancellation does not.
+//
+// If the policy returns nil, the URL is skipped and the failure is
+// recorded in the engine's summary, if it returns `ErrRetry` the URL
+// is queued again, any other error aborts the crawl.
+//
+// A policy must be safe to use from multiple goroutines.
+type ErrorPolicy func(ctx context.Context, url *URL, err error) error
+
+// AbortOnError returns a policy that aborts the crawl on any error.
+//
+// This is the default policy.
+func AbortOnError() ErrorPolicy {
+	return func(_ context.Context, _ *URL, err error) error {
+		return err
+	}
+}
+
+// SkipOnError returns a policy that skips all failed URLs.
+//
+// Skipped URLs are logged with the engine's logger,
+// see `EngineConfig.Logger`.
+func SkipOnError() ErrorPolicy {
+	return func(_ context.Context, _ *URL, _ error) error {
+		return nil
+	}
+}
+
+// RetryOnError returns a policy that retries failed URLs later.
+//
+// A failed URL is queued again up to `n` times, when
+// the URL fails more than `n` times it is skipped.
+func RetryOnError(n int) ErrorPolicy {
+	var attempts sync.Map
+
+	return func(_ context.Context, url *URL, err error) error {
+		v, _ := attempts.LoadOrStore(url.String(), new(atomic.Int64))
+		if v.(*atomic.Int64).Add(1) > int64(n) {
+			return nil
+		}
+		return ErrRetry
+	}
+}
+
+// StopAfterErrors returns a policy that skips failed URLs
+// until `n` errors occur and then aborts the crawl.
+func StopAfterErrors(n int) ErrorPolicy {
+	var count atomic.Int64
+
+	return func(_ context.Context, _ *URL, err error) error {
+		if count.Add(1) >= int64(n) {
+			return fmt.Errorf("ant: stopped after %d errors - %w", n, err)
+		}
+		return nil
+	}
+}
+
+// Failure represents a skipped URL.
+type Failure struct {
+	URL *URL
+	Err error
+}
+
+// Summary represents a crawl summary.
+type Summary struct {
+	// Pages is the amount of pages that were scraped.
+	Pages int
+
+	// Bytes is the amount of body bytes that were downloaded.
+	Bytes int64
+
+	// Failures contains all URLs that were skipped by
+	// the error policy or because their content type is
+	// not allowed, in the order they failed.
+	Failures []Failure
+}
+
+// Summary returns the crawl summary.
+//
+// The method is typically called once `Run()` returns.
+func (eng *Engine) Summary() Summary {
+	eng.failmu.Lock()
+	defer eng.failmu.Unlock()
+
+	return Summary{
+		Pages:    int(eng.visited.Load()),
+		Bytes:    eng.budgets.bytes.Load(),
+		Failures: append([]Failure(nil), eng.failures...),
+	}
+}
+
+// Fail records a failure of url.
+func (eng *Engine) fail(url *URL, err error) {
+	eng.failmu.Lock()
+	defer eng.failmu.Unlock()
+	eng.failures = append(eng.failures, Failure{URL: url, Err: err})
+}
+
+// Failed handles an error that occurred while processing url.
+//
+// The method calls the error policy and returns a non-nil
+// error only if the crawl should be aborted.
+func (eng *Engine) failed(ctx context.Context, url *URL, m meta, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	switch perr := eng.onError(ctx, url, err); {
+	case perr == nil:
+		eng.logger.WarnContext(ctx, "ant: skip", append(attrs(url), "error", err)...)
+		eng.fail(url, err)
+		return nil
+
+	case errors.Is(perr, ErrRetry):
+		eng.logger.WarnContext(ctx, "ant: retry later", append(attrs(url), "error", err)...)
+		v := eng.pending.add(url, m)
+		switch err := eng.push(ctx, []*tracked{v}, Requests{m.request(url)}); {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return fmt.Errorf("ant: retry %q - %w", url, err)
+		}
+		return nil
+
+	default:
+		return perr
+	}
+}
@@ -0,0 +1,128 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorPolicy(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, failing(visitor, "/about.html", 100))
+		var srv = server(t, "example.com")
+
+		eng.onError = SkipOnError()
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		sort.Strings(visitor.paths)
+		assert.Equal([]string{
+			"/",
+			"/a.html",
+			"/b.html",
+			"/products.html",
+		}, visitor.paths)
+
+		sum := eng.Summary()
+		assert.Equal(5, sum.Pages)
+		assert.Equal(1, len(sum.Failures))
+		assert.Equal(srv.URL+"/about.html", sum.Failures[0].URL.String())
+		assert.True(errors.Is(sum.Failures[0].Err, io.ErrUnexpectedEOF))
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, failing(visitor, "/about.html", 2))
+		var srv = server(t, "example.com")
+
+		eng.onError = RetryOnError(2)
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		sort.Strings(visitor.paths)
+		assert.Equal([]string{
+			"/",
+			"/a.html",
+			"/about.html",
+			"/b.html",
+			"/products.html",
+		}, visitor.paths)
+		assert.Equal(0, len(eng.Summary().Failures))
+	})
+
+	t.Run("retry exhausted", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var visitor = &visitor{}
+		var eng = setup(t, failing(visitor, "/about.html", 3))
+		var srv = server(t, "example.com")
+
+		eng.onError = RetryOnError(2)
+		err := eng.Run(ctx, srv.URL)
+		assert.NoError(err)
+
+		sum := eng.Summary()
+		assert.Equal(1, len(sum.Failures))
+		assert.Equal(srv.URL+"/about.html", sum.Failures[0].URL.String())
+	})
+
+	t.Run("stop after errors", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var scraper = scraperFunc(func(context.Context, *Page) (URLs, error) {
+			return nil, io.ErrUnexpectedEOF
+		})
+		var eng = setup(t, scraper)
+		var srv = server(t, "example.com")
+
+		eng.onError = StopAfterErrors(2)
+		err := eng.Run(ctx, srv.URL, srv.URL+"/about.html", srv.URL+"/products.html")
+
+		assert.Error(err)
+		assert.True(errors.Is(err, io.ErrUnexpectedEOF))
+		assert.Contains(err.Error(), "stopped after 2 errors")
+		assert.Equal(1, len(eng.Summary().Failures))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var eng = setup(t, failing(&visitor{}, "/about.html", 1))
+		var srv = server(t, "example.com")
+
+		err := eng.Run(ctx, srv.URL)
+		assert.Error(err)
+		assert.True(errors.Is(err, io.ErrUnexpectedEOF))
+		assert.Equal(0, len(eng.Summary().Failures))
+	})
+}
+
+// Failing returns a scraper that fails the first
+// `n` times a page with path is scraped.
+func failing(s Scraper, path string, n int) Scraper {
+	var mu sync.Mutex
+
+	return scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+		mu.Lock()
+		fail := p.URL.Path == path && n > 0
+		if fail {
+			n--
+		}
+		mu.Unlock()
+
+		if fail {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return s.Scrape(ctx, p)
+	})
+}
@@ -0,0 +1,222 @@
+package ant
+
+import (
+	"container/heap"
+	"context"
+	"io"
+	"sync"
+)
+
+// Item represents a prioritized URL.
+type item struct {
+	url      *URL
+	priority float64
+	seq      uint64
+}
+
+// Items implements a max heap of items.
+//
+// Items with equal priority are ordered by
+// their sequence, which keeps them FIFO.
+type items []item
+
+// Len implementation.
+func (h items) Len() int { return len(h) }
+
+// Less implementation.
+func (h items) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+// Swap implementation.
+func (h items) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Push implementation.
+func (h *items) Push(x any) { *h = append(*h, x.(item)) }
+
+// Pop implementation.
+func (h *items) Pop() any {
+	old := *h
+	n := len(old)
+	v := old[n-1]
+	old[n-1] = item{}
+	*h = old[:n-1]
+	return v
+}
+
+// Priorityqueue implements a priority queue.
+type priorityqueue struct {
+	pending items
+	score   func(*URL) float64
+	max     int
+	seq     uint64
+	cond    *sync.Cond
+	stopped bool
+	wg      *sync.WaitGroup
+}
+
+// PriorityQueue returns a new in-memory priority queue.
+//
+// The queue hands out URLs with the highest score first, the
+// score of each URL is computed once by calling `score` when
+// it is queued, URLs with equal scores are handed out in the
+// order they were queued.
+//
+// When max > 0 the queue holds at most `max` URLs, when a URL
+// is queued into a full queue the URL with the lowest score is
+// evicted, if the queued URL has the lowest score it is evicted
+// instead, evicted URLs are returned in a `*QueueFullError`.
+//
+// If score is nil, all URLs have the same score and
+// the queue behaves like a FIFO queue.
+//
+// The queue implements `RequestQueue`, the score of a
+// request is the sum of its URL's score and its `Priority`.
+func PriorityQueue(score func(*URL) float64, max int) Queue {
+	return &priorityqueue{
+		score: score,
+		max:   max,
+		cond:  sync.NewCond(&sync.Mutex{}),
+		wg:    &sync.WaitGroup{},
+	}
+}
+
+// Enqueue implementation.
+func (pq *priorityqueue) Enqueue(ctx context.Context, urls URLs) error {
+	return pq.EnqueueRequests(ctx, RequestsOf(urls))
+}
+
+// EnqueueRequests implementation.
+func (pq *priorityqueue) EnqueueRequests(ctx context.Context, reqs Requests) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	pq.cond.L.Lock()
+	defer pq.cond.L.Unlock()
+
+	if pq.stopped {
+		return io.EOF
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var evicted URLs
+
+	for _, r := range reqs {
+		if u, ok := pq.push(r.URL, pq.priority(r.URL)+r.Priority); ok {
+			evicted = append(evicted, u)
+		}
+	}
+
+	pq.cond.Broadcast()
+
+	if len(evicted) > 0 {
+		return &QueueFullError{Evicted: evicted}
+	}
+
+	return nil
+}
+
+// Push pushes the given URL with priority.
+//
+// When the queue is full, the method returns
+// the evicted URL and true.
+func (pq *priorityqueue) push(u *URL, priority float64) (*URL, bool) {
+	pq.seq++
+
+	var evicted *URL
+
+	if pq.max > 0 && len(pq.pending) >= pq.max {
+		j := pq.lowest()
+		if pq.pending[j].priority >= priority {
+			return u, true
+		}
+		evicted = heap.Remove(&pq.pending, j).(item).url
+		pq.wg.Done()
+	}
+
+	heap.Push(&pq.pending, item{
+		url:      u,
+		priority: priority,
+		seq:      pq.seq,
+	})
+	pq.wg.Add(1)
+
+	return evicted, evicted != nil
+}
+
+// Lowest returns the index of the item that should be evicted.
+//
+// The lowest priority item is always a leaf, the method only
+// scans the 2nd half of the heap.
+func (pq *priorityqueue) lowest() int {
+	var h = pq.pending
+	var j = len(h) / 2
+
+	for i := j + 1; i < len(h); i++ {
+		if h.Less(j, i) {
+			j = i
+		}
+	}
+
+	return j
+}
+
+// Priority returns the priority of u.
+func (pq *priorityqueue) priority(u *URL) float64 {
+	if pq.score != nil {
+		return pq.score(u)
+	}
+	return 0
+}
+
+// Dequeue implementation.
+func (pq *priorityqueue) Dequeue(ctx context.Context) (*URL, error) {
+	pq.cond.L.Lock()
+	defer pq.cond.L.Unlock()
+
+	for len(pq.pending) == 0 {
+		if pq.stopped {
+			return nil, io.EOF
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pq.cond.Wait()
+	}
+
+	v := heap.Pop(&pq.pending).(item)
+	return v.url, nil
+}
+
+// Done implementation.
+func (pq *priorityqueue) Done(_ context.Context, _ *URL) error {
+	pq.wg.Done()
+	return nil
+}
+
+// Wait implementation.
+func (pq *priorityqueue) Wait() {
+	pq.wg.Wait()
+}
+
+// Close implementation.
+func (pq *priorityqueue) Close(_ context.Context) error {
+	pq.cond.L.Lock()
+	defer pq.cond.L.Unlock()
+
+	for range pq.pending {
+		pq.wg.Done()
+	}
+
+	pq.stopped = true
+	pq.pending = pq.pending[:0]
+	pq.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,156 @@
+package ant
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityqueue(t *testing.T) {
+	var depth = func(u *URL) float64 {
+		return -float64(strings.Count(u.Path, "/"))
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = PriorityQueue(depth, 0)
+
+		urls := parseURLs(t,
+			"https://a/1/2/3",
+			"https://a/1",
+			"https://a/1/2",
+		)
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		assert.Equal([]string{
+			"https://a/1",
+			"https://a/1/2",
+			"https://a/1/2/3",
+		}, dequeueAll(t, q, 3))
+	})
+
+	t.Run("stable", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = PriorityQueue(depth, 0)
+
+		urls := parseURLs(t,
+			"https://a/1/x",
+			"https://b/1",
+			"https://c/1/x",
+			"https://d/1",
+			"https://e/1",
+		)
+		assert.NoError(q.Enqueue(ctx, urls))
+
+		assert.Equal([]string{
+			"https://b/1",
+			"https://d/1",
+			"https://e/1",
+			"https://a/1/x",
+			"https://c/1/x",
+		}, dequeueAll(t, q, 5))
+	})
+
+	t.Run("requests", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = PriorityQueue(depth, 0)
+
+		reqs := RequestsOf(parseURLs(t,
+			"https://a/1",
+			"https://a/1/2",
+			"https://a/1/2/3",
+		))
+		reqs[2].Priority = 10
+		assert.NoError(q.(RequestQueue).EnqueueRequests(ctx, reqs))
+
+		assert.Equal([]string{
+			"https://a/1/2/3",
+			"https://a/1",
+			"https://a/1/2",
+		}, dequeueAll(t, q, 3))
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var q = PriorityQueue(depth, 2)
+
+		urls := parseURLs(t,
+			"https://a/1/2",
+			"https://a/1",
+			"https://a/1/2/3",
+			"https://b/1",
+			"https://c/1",
+		)
+
+		var err *QueueFullError
+		assert.ErrorAs(q.Enqueue(ctx, urls), &err)
+		assert.ErrorIs(err, ErrQueueFull)
+		assert.Equal(parseURLs(t,
+			"https://a/1/2/3",
+			"https://a/1/2",
+			"https://c/1",
+		), err.Evicted)
+
+		assert.Equal([]string{
+			"https://a/1",
+			"https://b/1",
+		}, dequeueAll(t, q, 2))
+
+		q.Done(ctx, nil)
+		q.Done(ctx, nil)
+		q.Wait()
+	})
+
+	t.Run("engine bounded", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var evicted atomic.Int64
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Queue:    PriorityQueue(nil, 1),
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				return p.URLs(), nil
+			}),
+			Hooks: Hooks{
+				OnDiscard: func(_ context.Context, urls URLs, reason Discard) {
+					if reason == DiscardEvicted {
+						evicted.Add(int64(len(urls)))
+					}
+				},
+			},
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+
+		queued, inflight := eng.pending.snapshot()
+		assert.NotZero(evicted.Load())
+		assert.Empty(queued)
+		assert.Empty(inflight)
+	})
+}
+
+func dequeueAll(t testing.TB, q Queue, n int) []string {
+	var ctx = context.Background()
+	var ret = make([]string, 0, n)
+
+	t.Helper()
+
+	for j := 0; j < n; j++ {
+		u, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("dequeue: %s", err)
+		}
+		ret = append(ret, u.String())
+	}
+
+	return ret
+}
@@ -2,10 +2,39 @@ package ant
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"sync"
 )
 
+// ErrQueueFull is returned when a bounded queue is full.
+//
+// Queues return a `*QueueFullError` that wraps the error, use
+// `errors.Is(err, ant.ErrQueueFull)` to check for it.
+var ErrQueueFull = errors.New("ant: queue full")
+
+// QueueFullError represents URLs evicted from a full queue.
+//
+// The error is returned from `Enqueue()` when a bounded queue
+// evicted URLs to make room, all other URLs were queued.
+//
+// Evicted URLs are no longer queued, they are never
+// dequeued and must not be acknowledged with `Done()`.
+type QueueFullError struct {
+	Evicted URLs
+}
+
+// Error implementation.
+func (err *QueueFullError) Error() string {
+	return fmt.Sprintf("ant: queue full, evicted %d urls", len(err.Evicted))
+}
+
+// Unwrap implementation.
+func (err *QueueFullError) Unwrap() error {
+	return ErrQueueFull
+}
+
 // Queue represents a URL queue.
 //
 // A queue must be safe to use from multiple goroutines.
@@ -16,6 +45,9 @@ type Queue interface {
 	// closed and a context error if the context was
 	// canceled.
 	//
+	// Bounded queues return a `*QueueFullError` with
+	// the URLs that were evicted to make room.
+	//
 	// Any other error will be treated as a critical
 	// error and will be porpagated.
 	Enqueue(ctx context.Context, urls URLs) error
@@ -2,6 +2,7 @@ package ant_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/yields/ant"
 	"github.com/yields/ant/anttest"
@@ -13,6 +14,28 @@ func TestQueue(t *testing.T) {
 	})
 }
 
+func TestDiskQueue(t *testing.T) {
+	anttest.TestQueue(t, func(t testing.TB) ant.Queue {
+		q, err := ant.DiskQueue(t.TempDir())
+		if err != nil {
+			t.Fatalf("disk queue: %s", err)
+		}
+		return q
+	})
+}
+
+func TestHostQueue(t *testing.T) {
+	anttest.TestQueue(t, func(t testing.TB) ant.Queue {
+		return ant.HostQueue(time.Millisecond)
+	})
+}
+
+func TestPriorityQueue(t *testing.T) {
+	anttest.TestQueue(t, func(t testing.TB) ant.Queue {
+		return ant.PriorityQueue(nil, 0)
+	})
+}
+
 func BenchmarkQueue(b *testing.B) {
 	anttest.BenchmarkQueue(b, func(t testing.TB) ant.Queue {
 		return ant.MemoryQueue(5)
@@ -0,0 +1,260 @@
+package ant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Request represents a request to fetch a page.
+//
+// Scrapers that implement `RequestScraper` return requests instead
+// of URLs, which allows them to fetch pages with a different method,
+// headers or body and to attach metadata for the next scrape.
+//
+// The engine de-duplicates GET requests by their URL and other
+// requests by their method, URL and body, e.g. a form that posts
+// to a page that was already visited is submitted once.
+type Request struct {
+	// Method is the HTTP method to use.
+	//
+	// If empty, defaults to "GET".
+	Method string
+
+	// URL is the URL to fetch.
+	URL *URL
+
+	// Header contains additional headers to send.
+	//
+	// The headers are added to the fetcher's headers,
+	// a header that is set replaces the fetcher's header.
+	Header http.Header
+
+	// Body is the request body.
+	//
+	// The body is sent again when the request is retried.
+	Body []byte
+
+	// Priority is the request's priority.
+	//
+	// Queues that implement `RequestQueue` may hand out
+	// requests with a higher priority first, other queues
+	// ignore it.
+	Priority float64
+
+	// Depth is the depth of the request.
+	//
+	// If <= 0, the engine sets the depth, requests returned
+	// by a scraper have a depth of the scraped page's depth + 1.
+	Depth int
+
+	// Session is the name of the cookie session to use.
+	//
+	// It takes precedence over the session of the context,
+	// see `Sessions`, it is ignored when the fetcher does
+	// not use sessions.
+	//
+	// If empty, the fetcher selects the session.
+	Session string
+
+	// Meta is user metadata.
+	//
+	// The metadata is available to the scraper as `Page.Meta`, it
+	// must be JSON serializable to be included in a checkpoint.
+	//
+	// A checkpoint stores the metadata as plain JSON, values of
+	// resumed requests are decoded as JSON values, e.g. numbers
+	// are float64 and structs are maps, the scraper must accept
+	// both the original and decoded values.
+	Meta map[string]any
+}
+
+// NewRequest returns a new GET request for url.
+func NewRequest(url *URL) *Request {
+	return &Request{URL: url}
+}
+
+// Requests represents a slice of requests.
+type Requests []*Request
+
+// URLs returns the URLs of all requests.
+func (reqs Requests) URLs() URLs {
+	var ret = make(URLs, 0, len(reqs))
+
+	for _, r := range reqs {
+		ret = append(ret, r.URL)
+	}
+
+	return ret
+}
+
+// Only returns the requests whose URL is in urls.
+//
+// Every URL in urls keeps a single request, in order, a URL
+// that is in urls twice keeps the first two requests.
+func (reqs Requests) only(urls URLs) Requests {
+	var counts = make(map[string]int, len(urls))
+	var ret = make(Requests, 0, len(urls))
+
+	for _, u := range urls {
+		counts[u.String()]++
+	}
+
+	for _, r := range reqs {
+		if k := r.URL.String(); counts[k] > 0 {
+			ret = append(ret, r)
+			counts[k]--
+		}
+	}
+
+	return ret
+}
+
+// Keys returns the de-duplication keys of all requests.
+func (reqs Requests) keys() URLs {
+	var ret = make(URLs, 0, len(reqs))
+
+	for _, r := range reqs {
+		ret = append(ret, r.key())
+	}
+
+	return ret
+}
+
+// Dedupe returns the first request of each key in keys.
+func (reqs Requests) dedupe(keys URLs) Requests {
+	var keep = make(map[string]bool, len(keys))
+	var ret = make(Requests, 0, len(keys))
+
+	for _, k := range keys {
+		keep[k.String()] = true
+	}
+
+	for _, r := range reqs {
+		if k := r.key().String(); keep[k] {
+			ret = append(ret, r)
+			delete(keep, k)
+		}
+	}
+
+	return ret
+}
+
+// Key returns the URL to de-duplicate the request by.
+//
+// GET requests are de-duplicated by their URL, other requests
+// by their method, URL and body, which are added to the URL's
+// fragment so that they can be passed to the deduper.
+func (r *Request) key() *URL {
+	if r.Method == "" || r.Method == "GET" {
+		return r.URL
+	}
+
+	var u = *r.URL
+	var sum = sha256.Sum256(r.Body)
+
+	u.Fragment = r.Method + ":" + hex.EncodeToString(sum[:])
+	u.RawFragment = ""
+
+	return &u
+}
+
+// RequestsOf returns GET requests for all urls.
+func RequestsOf(urls URLs) Requests {
+	var ret = make(Requests, 0, len(urls))
+
+	for _, u := range urls {
+		ret = append(ret, NewRequest(u))
+	}
+
+	return ret
+}
+
+// RequestScraper represents a scraper that returns requests.
+//
+// When the engine's scraper implements the interface the engine
+// calls `ScrapeRequests()` instead of `Scrape()`, use `ScraperFrom()`
+// to configure a scraper that only implements `RequestScraper`.
+type RequestScraper interface {
+	// ScrapeRequests scrapes the given page.
+	//
+	// The method can return a set of requests that should
+	// be queued and fetched next.
+	//
+	// If the scraper returns an error and it implements
+	// a `Temporary() bool` method that returns true it will
+	// be retried.
+	ScrapeRequests(ctx context.Context, p *Page) (Requests, error)
+}
+
+// RequestQueue represents a queue that accepts requests.
+//
+// When the engine's queue implements the interface the engine
+// calls `EnqueueRequests()` instead of `Enqueue()`, dequeued
+// URLs are mapped back to their requests by the engine.
+//
+// A queue should hand out the `*URL` of each request as is, a
+// URL that is queued with more than one request, e.g. with a GET
+// and a POST request, is otherwise mapped to its requests in the
+// order they were queued.
+type RequestQueue interface {
+	Queue
+
+	// EnqueueRequests enqueues the given requests.
+	//
+	// The method behaves like `Enqueue()`, it may
+	// use the requests to order the URLs.
+	EnqueueRequests(ctx context.Context, reqs Requests) error
+}
+
+// ScraperFrom returns a scraper that calls rs.
+//
+// The returned scraper implements both `Scraper` and `RequestScraper`
+// which allows request scrapers to be used as `EngineConfig.Scraper`,
+// its `Scrape()` method returns the URLs of the requests.
+func ScraperFrom(rs RequestScraper) Scraper {
+	return requestScraper{rs}
+}
+
+// RequestScraper adapts a request scraper to a scraper.
+type requestScraper struct {
+	RequestScraper
+}
+
+// Scrape implementation.
+func (s requestScraper) Scrape(ctx context.Context, p *Page) (URLs, error) {
+	reqs, err := s.ScrapeRequests(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return reqs.URLs(), nil
+}
+
+// ScrapeRequests calls s and returns requests.
+//
+// If s implements `RequestScraper` the method calls `ScrapeRequests()`,
+// otherwise the URLs that s returns are converted to GET requests.
+func scrapeRequests(ctx context.Context, s Scraper, p *Page) (Requests, error) {
+	if rs, ok := s.(RequestScraper); ok {
+		return rs.ScrapeRequests(ctx, p)
+	}
+
+	urls, err := s.Scrape(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return RequestsOf(urls), nil
+}
+
+// EnqueueRequests enqueues reqs to q.
+//
+// If q implements `RequestQueue` the method calls
+// `EnqueueRequests()`, otherwise it enqueues the URLs.
+func enqueueRequests(ctx context.Context, q Queue, reqs Requests) error {
+	if rq, ok := q.(RequestQueue); ok {
+		return rq.EnqueueRequests(ctx, reqs)
+	}
+	return q.Enqueue(ctx, reqs.URLs())
+}
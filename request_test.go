@@ -0,0 +1,187 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest(t *testing.T) {
+	t.Run("run with requests", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var got []string
+		var meta map[string]any
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			got = append(got, r.Method+" "+r.URL.Path+" "+string(body)+" "+r.Header.Get("X-Token"))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/html")
+		}))
+		t.Cleanup(srv.Close)
+
+		eng := setup(t, ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+			if p.URL.Path != "/" {
+				mu.Lock()
+				meta = p.Meta
+				mu.Unlock()
+				return nil, nil
+			}
+
+			search := NewRequest(p.URL.ResolveReference(&URL{Path: "/search"}))
+			search.Method = "POST"
+			search.Header = http.Header{"x-token": {"secret"}}
+			search.Body = []byte("q=ant")
+			search.Meta = map[string]any{"q": "ant"}
+			return Requests{search, search}, nil
+		})))
+
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{
+			"GET /robots.txt  ",
+			"GET /  ",
+			"POST /search q=ant secret",
+		}, got)
+		assert.Equal(map[string]any{"q": "ant"}, meta)
+		assert.Equal(2, eng.Summary().Pages)
+	})
+
+	t.Run("run with requests depth", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = server(t, "example.com")
+		var v = &visitor{}
+
+		eng, err := NewEngine(EngineConfig{
+			MaxDepth: 1,
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				v.Scrape(ctx, p)
+				reqs := RequestsOf(p.URLs())
+				for _, r := range reqs {
+					r.Depth = 2
+				}
+				return reqs, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{"/"}, v.paths)
+	})
+
+	t.Run("run with requests priority", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var got []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			got = append(got, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/html")
+		}))
+		t.Cleanup(srv.Close)
+
+		eng, err := NewEngine(EngineConfig{
+			Queue:       PriorityQueue(nil, 0),
+			Concurrency: 1,
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				if p.URL.Path != "/" {
+					return nil, nil
+				}
+
+				form := p.URL.ResolveReference(&URL{Path: "/form"})
+				post := NewRequest(form)
+				post.Method = "POST"
+				post.Priority = 1
+				return Requests{NewRequest(form), post}, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{
+			"GET /robots.txt",
+			"GET /",
+			"POST /form",
+			"GET /form",
+		}, got)
+	})
+
+	t.Run("run with requests evicted", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var mu sync.Mutex
+		var got []string
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			got = append(got, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/html")
+		}))
+		t.Cleanup(srv.Close)
+
+		eng, err := NewEngine(EngineConfig{
+			Queue:       PriorityQueue(nil, 1),
+			Concurrency: 1,
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				if p.URL.Path != "/" {
+					return nil, nil
+				}
+
+				form := p.URL.ResolveReference(&URL{Path: "/form"})
+				post := NewRequest(form)
+				post.Method = "POST"
+				post.Priority = 1
+				return Requests{post, NewRequest(form)}, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{
+			"GET /robots.txt",
+			"GET /",
+			"POST /form",
+		}, got)
+	})
+
+	t.Run("scraper from", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var u = &URL{Scheme: "https", Host: "example.com"}
+
+		s := ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+			return Requests{NewRequest(u)}, nil
+		}))
+
+		urls, err := s.Scrape(ctx, &Page{})
+		assert.NoError(err)
+		assert.Equal(URLs{u}, urls)
+
+		reqs, err := scrapeRequests(ctx, s, &Page{})
+		assert.NoError(err)
+		assert.Equal(Requests{NewRequest(u)}, reqs)
+
+		reqs, err = scrapeRequests(ctx, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			return URLs{u}, nil
+		}), &Page{})
+		assert.NoError(err)
+		assert.Equal(Requests{NewRequest(u)}, reqs)
+	})
+}
+
+// RequestScraperFunc implements a request scraper.
+type requestScraperFunc func(context.Context, *Page) (Requests, error)
+
+// ScrapeRequests implementation.
+func (f requestScraperFunc) ScrapeRequests(ctx context.Context, p *Page) (Requests, error) {
+	return f(ctx, p)
+}
@@ -0,0 +1,245 @@
+package ant
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Attempt represents a failed fetch attempt.
+type Attempt struct {
+	// Request is the request that failed.
+	Request *Request
+
+	// Number is the attempt number, starting at 1.
+	Number int
+
+	// Response is the response, it is nil if no response
+	// was received, its body is already closed.
+	Response *http.Response
+
+	// Err is the error that occurred.
+	Err error
+
+	// Wait is the previous wait, it is 0 on the first attempt.
+	Wait time.Duration
+}
+
+// RetryPolicy represents a fetch retry policy.
+//
+// The fetcher calls the policy after every failed attempt, the
+// fetcher stops after `Fetcher.MaxAttempts` regardless of the policy.
+type RetryPolicy interface {
+	// Retry returns the duration to wait and true
+	// if the attempt a should be retried.
+	Retry(a Attempt) (time.Duration, bool)
+}
+
+// RetryOn configures which failures are retried.
+//
+// Temporary failures are always retried, these are
+// responses with a 429, 503 or 504 status.
+type RetryOn struct {
+	// Statuses is the additional response status
+	// codes to retry, e.g. 502.
+	Statuses []int
+
+	// ConnReset retries connections that were reset
+	// or closed by the server before a response was received.
+	ConnReset bool
+}
+
+// Retryable returns true if a should be retried.
+func (r RetryOn) retryable(a Attempt) bool {
+	if isTemporary(a.Err) {
+		return true
+	}
+
+	var ferr *FetchError
+	if errors.As(a.Err, &ferr) && slices.Contains(r.Statuses, ferr.Status) {
+		return true
+	}
+
+	if r.ConnReset && a.Response == nil {
+		return errors.Is(a.Err, syscall.ECONNRESET) ||
+			errors.Is(a.Err, io.EOF) ||
+			errors.Is(a.Err, io.ErrUnexpectedEOF)
+	}
+
+	return false
+}
+
+// ExponentialJitter implements exponential backoff with full jitter.
+//
+// The wait is a random duration between 0 and `Base * 2^attempt`,
+// capped at `Max`.
+type ExponentialJitter struct {
+	RetryOn
+
+	// Base is the base wait.
+	//
+	// When <= 0, it defaults to `50ms`.
+	Base time.Duration
+
+	// Max is the maximum wait.
+	//
+	// When <= 0, it defaults to `1s`.
+	Max time.Duration
+}
+
+// Retry implementation.
+func (p ExponentialJitter) Retry(a Attempt) (time.Duration, bool) {
+	if !p.retryable(a) {
+		return 0, false
+	}
+
+	var lo, hi = waits(p.Base, p.Max)
+	var ceil = lo
+
+	// Double the wait until it reaches max, which
+	// keeps high attempts from overflowing.
+	for n := 0; n < a.Number && ceil < hi; n++ {
+		if ceil > hi/2 {
+			ceil = hi
+			break
+		}
+		ceil *= 2
+	}
+
+	return rand.N(ceil + 1), true
+}
+
+// DecorrelatedJitter implements decorrelated jitter backoff.
+//
+// The wait is a random duration between `Base` and three times
+// the previous wait, capped at `Max`.
+type DecorrelatedJitter struct {
+	RetryOn
+
+	// Base is the base wait.
+	//
+	// When <= 0, it defaults to `50ms`.
+	Base time.Duration
+
+	// Max is the maximum wait.
+	//
+	// When <= 0, it defaults to `1s`.
+	Max time.Duration
+}
+
+// Retry implementation.
+func (p DecorrelatedJitter) Retry(a Attempt) (time.Duration, bool) {
+	if !p.retryable(a) {
+		return 0, false
+	}
+
+	var lo, hi = waits(p.Base, p.Max)
+	var prev = max(a.Wait, lo)
+	var wait = lo + rand.N(prev*3-lo+1)
+
+	return min(wait, hi), true
+}
+
+// Waits returns the base and max wait with defaults.
+func waits(base, max time.Duration) (time.Duration, time.Duration) {
+	if base <= 0 {
+		base = minBackoff
+	}
+	if max <= 0 {
+		max = maxBackoff
+	}
+	return base, max
+}
+
+// Quadratic implements the default retry policy.
+//
+// The wait grows quadratically with the attempt, starting
+// at min and capped at max, only temporary failures are retried.
+type quadratic struct {
+	min time.Duration
+	max time.Duration
+}
+
+// Retry implementation.
+func (p quadratic) Retry(a Attempt) (time.Duration, bool) {
+	return min(time.Duration(a.Number*a.Number)*p.min, p.max), isTemporary(a.Err)
+}
+
+// RetryBudget limits the retries per host.
+//
+// Every host starts with `Max` tokens, a retry takes a token and
+// every first attempt gives back `Ratio` tokens up to `Max`. Once
+// a host runs out of tokens its failures are not retried, which
+// keeps a failing host from occupying all workers with retries.
+//
+// A budget must not be copied after first use.
+type RetryBudget struct {
+	// Max is the maximum amount of tokens per host.
+	//
+	// When <= 0, it defaults to 10.
+	Max float64
+
+	// Ratio is the amount of tokens a first attempt gives back.
+	//
+	// When <= 0, it defaults to 0.1, which allows
+	// one retry for every 10 requests.
+	Ratio float64
+
+	mu    sync.Mutex
+	hosts map[string]float64
+}
+
+// Deposit deposits tokens for a first attempt to host.
+func (b *RetryBudget) deposit(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v, ok := b.hosts[host]; ok {
+		b.hosts[host] = min(v+b.ratio(), b.max())
+	}
+}
+
+// Withdraw withdraws a token for a retry to host.
+//
+// The method returns false if the host has no tokens left.
+func (b *RetryBudget) withdraw(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.hosts == nil {
+		b.hosts = make(map[string]float64)
+	}
+
+	v, ok := b.hosts[host]
+	if !ok {
+		v = b.max()
+	}
+
+	if v < 1 {
+		return false
+	}
+
+	b.hosts[host] = v - 1
+	return true
+}
+
+// Max returns the max tokens.
+func (b *RetryBudget) max() float64 {
+	if b.Max > 0 {
+		return b.Max
+	}
+	return 10
+}
+
+// Ratio returns the deposit ratio.
+func (b *RetryBudget) ratio() float64 {
+	if b.Ratio > 0 {
+		return b.Ratio
+	}
+	return 0.1
+}
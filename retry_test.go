@@ -0,0 +1,104 @@
+package ant
+
+import (
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	var temporary = &FetchError{Status: 503}
+	var badGateway = &FetchError{Status: 502}
+
+	t.Run("retry on", func(t *testing.T) {
+		var assert = require.New(t)
+		var reset = fmt.Errorf("ant: GET - %w", syscall.ECONNRESET)
+		var on = RetryOn{}
+
+		assert.True(on.retryable(Attempt{Err: temporary}))
+		assert.False(on.retryable(Attempt{Err: badGateway}))
+		assert.False(on.retryable(Attempt{Err: reset}))
+
+		on = RetryOn{Statuses: []int{502}, ConnReset: true}
+		assert.True(on.retryable(Attempt{Err: badGateway}))
+		assert.True(on.retryable(Attempt{Err: reset}))
+		assert.False(on.retryable(Attempt{Err: reset, Response: &http.Response{}}))
+		assert.False(on.retryable(Attempt{Err: &FetchError{Status: 500}}))
+	})
+
+	t.Run("temporary", func(t *testing.T) {
+		var assert = require.New(t)
+		var wrapped = fmt.Errorf("ant: GET - %w", temporaryError{})
+
+		assert.True(isTemporary(temporary))
+		assert.False(isTemporary(badGateway))
+		assert.False(isTemporary(wrapped))
+		assert.True(wrapsTemporary(wrapped))
+
+		assert.False(RetryOn{}.retryable(Attempt{Err: wrapped}))
+		_, ok := quadratic{time.Millisecond, time.Second}.Retry(Attempt{Number: 1, Err: wrapped})
+		assert.False(ok)
+	})
+
+	t.Run("exponential jitter", func(t *testing.T) {
+		var assert = require.New(t)
+		var p = ExponentialJitter{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+
+		for n := 1; n <= 40; n++ {
+			wait, ok := p.Retry(Attempt{Number: n, Err: temporary})
+			assert.True(ok)
+			assert.True(wait >= 0)
+			assert.True(wait <= min(10*time.Millisecond<<min(n, 10), 50*time.Millisecond))
+		}
+
+		_, ok := p.Retry(Attempt{Number: 1, Err: badGateway})
+		assert.False(ok)
+	})
+
+	t.Run("exponential jitter high attempts", func(t *testing.T) {
+		var assert = require.New(t)
+		var p = ExponentialJitter{Base: time.Minute, Max: time.Hour}
+
+		for _, n := range []int{28, 29, 31, 63, 64, 1 << 30} {
+			wait, ok := p.Retry(Attempt{Number: n, Err: temporary})
+			assert.True(ok)
+			assert.True(wait >= 0 && wait <= time.Hour)
+		}
+	})
+
+	t.Run("decorrelated jitter", func(t *testing.T) {
+		var assert = require.New(t)
+		var p = DecorrelatedJitter{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+		var wait time.Duration
+
+		for n := 1; n <= 40; n++ {
+			prev := max(wait, 10*time.Millisecond)
+			next, ok := p.Retry(Attempt{Number: n, Err: temporary, Wait: wait})
+			assert.True(ok)
+			assert.True(next >= 10*time.Millisecond)
+			assert.True(next <= min(3*prev, 50*time.Millisecond))
+			wait = next
+		}
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		var assert = require.New(t)
+		var b = &RetryBudget{Max: 2, Ratio: 0.5}
+
+		b.deposit("a")
+		assert.True(b.withdraw("a"))
+		assert.True(b.withdraw("a"))
+		assert.False(b.withdraw("a"))
+		assert.True(b.withdraw("b"))
+
+		b.deposit("a")
+		assert.False(b.withdraw("a"))
+		b.deposit("a")
+		assert.True(b.withdraw("a"))
+		assert.False(b.withdraw("a"))
+	})
+}
@@ -0,0 +1,309 @@
+package ant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrRetired is returned by a worker that was retired.
+var errRetired = errors.New("ant: worker retired")
+
+// Sema implements a resizable semaphore.
+type sema struct {
+	mu     sync.Mutex
+	limit  int
+	used   int
+	notify chan struct{}
+}
+
+// NewSema returns a new semaphore with limit n.
+//
+// If n <= 0, the semaphore has no limit.
+func newSema(n int) *sema {
+	return &sema{
+		limit:  n,
+		notify: make(chan struct{}),
+	}
+}
+
+// Acquire acquires the semaphore.
+//
+// The method blocks until the semaphore can be
+// acquired or the context is canceled.
+func (s *sema) acquire(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		if s.limit <= 0 || s.used < s.limit {
+			s.used++
+			s.mu.Unlock()
+			return nil
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release releases the semaphore.
+func (s *sema) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used--
+	s.broadcast()
+}
+
+// Resize sets the limit to n.
+//
+// When the limit shrinks, goroutines that hold the semaphore are
+// not interrupted, new acquires block until enough are released.
+func (s *sema) resize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = n
+	s.broadcast()
+}
+
+// Size returns the current limit.
+func (s *sema) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+// Broadcast wakes all waiting goroutines.
+func (s *sema) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Pool tracks the engine's workers.
+type pool struct {
+	mu      sync.Mutex
+	target  int
+	active  int
+	running bool
+	eg      *errgroup.Group
+	ctx     context.Context
+	spawn   func(ctx context.Context) error
+}
+
+// Start starts the target amount of workers.
+//
+// The workers are started with eg, spawn is
+// called with ctx by every worker.
+func (p *pool) start(ctx context.Context, eg *errgroup.Group, spawn func(context.Context) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.eg = eg
+	p.ctx = ctx
+	p.spawn = spawn
+	p.running = true
+	p.grow()
+}
+
+// Stop marks the pool as stopped.
+//
+// Once stopped no new workers are started.
+func (p *pool) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+}
+
+// Resize sets the target amount of workers to n.
+func (p *pool) resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.target = n
+	if p.running {
+		p.grow()
+	}
+}
+
+// Grow starts workers until the target is reached.
+func (p *pool) grow() {
+	for ; p.active < p.target; p.active++ {
+		p.eg.Go(func() error {
+			return p.spawn(p.ctx)
+		})
+	}
+}
+
+// Retire returns true if the calling worker should exit.
+func (p *pool) retire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active > p.target {
+		p.active--
+		return true
+	}
+
+	return false
+}
+
+// SetWorkers sets the amount of workers to n.
+//
+// When the engine is running, new workers are started immediately
+// and surplus workers exit once they handle their current URL, the
+// in-flight pages of a retired worker are processed as usual.
+//
+// If n <= 0, the engine uses a single worker.
+func (eng *Engine) SetWorkers(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	eng.pool.resize(n)
+	eng.logger.Info("ant: set workers", "workers", n)
+}
+
+// SetConcurrency sets the maximum amount of URLs to process at
+// any given time to n.
+//
+// The new limit applies immediately, when it shrinks pages that
+// are in-flight are not interrupted.
+//
+// If n <= 0, there's no limit.
+func (eng *Engine) SetConcurrency(n int) {
+	eng.sema.resize(n)
+	eng.logger.Info("ant: set concurrency", "concurrency", n)
+}
+
+// Autoscaler configures concurrency autoscaling.
+//
+// The autoscaler observes the latency and the errors of all fetches
+// and adjusts the engine's concurrency every interval, when the average
+// latency exceeds the target or the error rate exceeds the maximum
+// the concurrency is halved, otherwise it is increased by one.
+//
+// Errors are failed fetches and responses with a 429 or 5xx status.
+type Autoscaler struct {
+	// Min is the minimum concurrency.
+	//
+	// When <= 0, it defaults to 1.
+	Min int
+
+	// Max is the maximum concurrency.
+	//
+	// Must be greater than or equal to Min, otherwise
+	// NewEngine returns an error.
+	Max int
+
+	// TargetLatency is the maximum average fetch latency.
+	//
+	// When <= 0, it defaults to `1s`.
+	TargetLatency time.Duration
+
+	// MaxErrorRate is the maximum ratio of errors.
+	//
+	// When <= 0, it defaults to `0.1`.
+	MaxErrorRate float64
+
+	// Interval is the interval to adjust the concurrency at.
+	//
+	// When <= 0, it defaults to `5s`.
+	Interval time.Duration
+}
+
+// Scaler implements concurrency autoscaling.
+type scaler struct {
+	Autoscaler
+	mu      sync.Mutex
+	samples int
+	errors  int
+	latency time.Duration
+}
+
+// NewScaler returns a new scaler from a.
+func newScaler(a Autoscaler) (*scaler, error) {
+	if a.Min <= 0 {
+		a.Min = 1
+	}
+
+	if a.Max < a.Min {
+		return nil, errors.New("ant: autoscaler max must be greater than or equal to min")
+	}
+
+	if a.TargetLatency <= 0 {
+		a.TargetLatency = time.Second
+	}
+
+	if a.MaxErrorRate <= 0 {
+		a.MaxErrorRate = 0.1
+	}
+
+	if a.Interval <= 0 {
+		a.Interval = 5 * time.Second
+	}
+
+	return &scaler{Autoscaler: a}, nil
+}
+
+// Observe observes a single fetch.
+func (s *scaler) observe(d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples++
+	s.latency += d
+	if failed {
+		s.errors++
+	}
+}
+
+// Next returns the next concurrency given the current concurrency n.
+//
+// The method resets all observations, when there are no
+// observations it returns n clamped to the bounds.
+func (s *scaler) next(n int) int {
+	s.mu.Lock()
+	samples, errs, latency := s.samples, s.errors, s.latency
+	s.samples, s.errors, s.latency = 0, 0, 0
+	s.mu.Unlock()
+
+	if n <= 0 {
+		n = s.Max
+	}
+
+	if samples > 0 {
+		var avg = latency / time.Duration(samples)
+		var rate = float64(errs) / float64(samples)
+
+		if avg > s.TargetLatency || rate > s.MaxErrorRate {
+			n /= 2
+		} else {
+			n++
+		}
+	}
+
+	return min(max(n, s.Min), s.Max)
+}
+
+// Run adjusts the engine's concurrency until ctx is done.
+func (s *scaler) run(ctx context.Context, eng *Engine) {
+	var ticker = time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cur := eng.sema.size()
+			if n := s.next(cur); n != cur {
+				eng.SetConcurrency(n)
+			}
+		}
+	}
+}
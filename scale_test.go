@@ -0,0 +1,195 @@
+package ant
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScale(t *testing.T) {
+	t.Run("sema resize", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var s = newSema(1)
+
+		assert.NoError(s.acquire(ctx))
+
+		subctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(s.acquire(subctx), context.DeadlineExceeded)
+
+		var errc = make(chan error, 1)
+		go func() { errc <- s.acquire(ctx) }()
+
+		s.resize(2)
+		assert.NoError(<-errc)
+		assert.Equal(2, s.size())
+
+		s.release()
+		s.release()
+	})
+
+	t.Run("sema unlimited", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var s = newSema(0)
+
+		for i := 0; i < 100; i++ {
+			assert.NoError(s.acquire(ctx))
+		}
+	})
+
+	t.Run("set concurrency and workers", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = tree(t, 63)
+		var eng *Engine
+		var active, peak, pages int64
+
+		eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			if atomic.AddInt64(&pages, 1) == 1 {
+				eng.SetConcurrency(4)
+				eng.SetWorkers(4)
+			}
+
+			n := atomic.AddInt64(&active, 1)
+			defer atomic.AddInt64(&active, -1)
+			for {
+				v := atomic.LoadInt64(&peak)
+				if n <= v || atomic.CompareAndSwapInt64(&peak, v, n) {
+					break
+				}
+			}
+
+			time.Sleep(2 * time.Millisecond)
+			return p.URLs(), nil
+		}))
+
+		eng.impolite = true
+		err := eng.Run(ctx, srv.URL+"/0")
+
+		assert.NoError(err)
+		assert.Equal(int64(63), pages)
+		assert.True(peak > 1, "peak concurrency %d", peak)
+		assert.True(peak <= 4, "peak concurrency %d", peak)
+	})
+
+	t.Run("retire workers", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = tree(t, 63)
+		var eng *Engine
+		var pages int64
+
+		eng = setup(t, scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+			if atomic.AddInt64(&pages, 1) == 1 {
+				eng.SetWorkers(1)
+			}
+			return p.URLs(), nil
+		}))
+
+		eng.impolite = true
+		eng.pool.target = 4
+		err := eng.Run(ctx, srv.URL+"/0")
+
+		assert.NoError(err)
+		assert.Equal(int64(63), pages)
+		assert.Equal(1, eng.pool.active)
+	})
+
+	t.Run("autoscaler", func(t *testing.T) {
+		var cases = []struct {
+			title   string
+			current int
+			latency time.Duration
+			errors  int
+			samples int
+			next    int
+		}{
+			{"increase", 4, time.Millisecond, 0, 10, 5},
+			{"increase max", 8, time.Millisecond, 0, 10, 8},
+			{"latency", 8, 2 * time.Second, 0, 10, 4},
+			{"errors", 8, time.Millisecond, 2, 10, 4},
+			{"decrease min", 2, 2 * time.Second, 0, 10, 2},
+			{"no samples", 5, 0, 0, 0, 5},
+			{"unlimited", 0, 0, 0, 0, 8},
+		}
+
+		for _, c := range cases {
+			t.Run(c.title, func(t *testing.T) {
+				var assert = require.New(t)
+
+				s, err := newScaler(Autoscaler{Min: 2, Max: 8})
+				assert.NoError(err)
+
+				for i := 0; i < c.samples; i++ {
+					s.observe(c.latency, i < c.errors)
+				}
+
+				assert.Equal(c.next, s.next(c.current))
+			})
+		}
+	})
+
+	t.Run("autoscaler invalid", func(t *testing.T) {
+		var assert = require.New(t)
+
+		_, err := NewEngine(EngineConfig{
+			Scraper:    &visitor{},
+			Autoscaler: &Autoscaler{Min: 4, Max: 2},
+		})
+
+		assert.EqualError(err, `ant: autoscaler max must be greater than or equal to min`)
+	})
+
+	t.Run("autoscaler run", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = tree(t, 15)
+
+		eng, err := NewEngine(EngineConfig{
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				time.Sleep(5 * time.Millisecond)
+				return p.URLs(), nil
+			}),
+			Impolite: true,
+			Autoscaler: &Autoscaler{
+				Min:      1,
+				Max:      4,
+				Interval: time.Millisecond,
+			},
+		})
+		assert.NoError(err)
+		assert.Equal(1, eng.sema.size())
+
+		assert.NoError(eng.Run(ctx, srv.URL+"/0"))
+		assert.True(eng.sema.size() > 1)
+	})
+}
+
+// Tree returns a server that serves a binary tree of n pages.
+//
+// The page at `/i` links to `/2i+1` and `/2i+2`.
+func tree(t testing.TB, n int) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil || i >= n {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<a href="/%d"></a><a href="/%d"></a>`, 2*i+1, 2*i+2)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
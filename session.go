@@ -0,0 +1,286 @@
+package ant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Jar implements a cookie jar.
+//
+// The jar implements `http.CookieJar` and
+// keeps its cookies so that they can be saved.
+type Jar struct {
+	jar     *cookiejar.Jar
+	mu      sync.Mutex
+	entries map[string]jarEntry
+}
+
+// JarEntry represents a saved cookie.
+type jarEntry struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewJar returns a new empty jar.
+func NewJar() *Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	return &Jar{
+		jar:     jar,
+		entries: make(map[string]jarEntry),
+	}
+}
+
+// SetCookies implementation.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+	j.record(u, cookies, time.Now())
+}
+
+// Cookies implementation.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Record records the cookies that were set from u.
+//
+// Relative expiry is converted to an absolute time, deleted
+// and expired cookies are removed.
+func (j *Jar) record(u *url.URL, cookies []*http.Cookie, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range cookies {
+		var domain, dir = c.Domain, c.Path
+
+		if domain == "" {
+			domain = u.Hostname()
+		}
+
+		if dir == "" {
+			dir = path.Dir(u.EscapedPath())
+		}
+
+		if !strings.HasPrefix(dir, "/") {
+			dir = "/"
+		}
+
+		key := domain + ";" + dir + ";" + c.Name
+
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && !c.Expires.After(now)) {
+			delete(j.entries, key)
+			continue
+		}
+
+		cookie := *c
+		if cookie.MaxAge > 0 {
+			cookie.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			cookie.MaxAge = 0
+		}
+
+		j.entries[key] = jarEntry{
+			URL:    u.Scheme + "://" + u.Host + u.EscapedPath(),
+			Cookie: &cookie,
+		}
+	}
+}
+
+// Snapshot returns all cookies that did not expire.
+func (j *Jar) snapshot(now time.Time) []jarEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var ret = make([]jarEntry, 0, len(j.entries))
+
+	for _, e := range j.entries {
+		if e.Cookie.Expires.IsZero() || e.Cookie.Expires.After(now) {
+			ret = append(ret, e)
+		}
+	}
+
+	return ret
+}
+
+// Restore sets all saved cookies.
+func (j *Jar) restore(entries []jarEntry) error {
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return fmt.Errorf("ant: parse cookie url %q - %w", e.URL, err)
+		}
+		j.SetCookies(u, []*http.Cookie{e.Cookie})
+	}
+	return nil
+}
+
+// Sessions manages cookie sessions.
+//
+// Every session has its own cookie jar, the fetcher uses the
+// request's `Session`, the session that is set on the request
+// context with `WithSession()` or, if none is set, the host's
+// session when PerHost is true and the default session otherwise.
+//
+// The sessions can be saved to a file and loaded again to
+// reuse cookies between runs, e.g. to stay logged in.
+//
+// A sessions value must not be copied after first use.
+type Sessions struct {
+	// PerHost uses a session per host.
+	//
+	// When true, requests without a session use a session
+	// named by the request's hostname.
+	PerHost bool
+
+	mu   sync.Mutex
+	jars map[string]*Jar
+}
+
+// SessionKey is the context key of the session name.
+type sessionKey struct{}
+
+// WithSession returns a new context with session name.
+//
+// All requests that the fetcher makes with the
+// context use the cookie jar of the session.
+func WithSession(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, name)
+}
+
+// Jar returns the cookie jar of session name.
+//
+// If the session does not exist, it is created.
+func (s *Sessions) Jar(name string) *Jar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jars == nil {
+		s.jars = make(map[string]*Jar)
+	}
+
+	jar, ok := s.jars[name]
+	if !ok {
+		jar = NewJar()
+		s.jars[name] = jar
+	}
+
+	return jar
+}
+
+// Jar returns the jar to use for request r with ctx.
+func (s *Sessions) jar(ctx context.Context, r *Request) *Jar {
+	if s == nil {
+		return nil
+	}
+
+	if r.Session != "" {
+		return s.Jar(r.Session)
+	}
+
+	if name, ok := ctx.Value(sessionKey{}).(string); ok {
+		return s.Jar(name)
+	}
+
+	if s.PerHost {
+		return s.Jar(r.URL.Hostname())
+	}
+
+	return s.Jar("")
+}
+
+// Save writes all sessions to w.
+//
+// Expired cookies are not written.
+func (s *Sessions) Save(w io.Writer) error {
+	var now = time.Now()
+	var all = make(map[string][]jarEntry)
+
+	s.mu.Lock()
+	for name, jar := range s.jars {
+		all[name] = jar.snapshot(now)
+	}
+	s.mu.Unlock()
+
+	if err := json.NewEncoder(w).Encode(all); err != nil {
+		return fmt.Errorf("ant: save sessions - %w", err)
+	}
+
+	return nil
+}
+
+// Load reads sessions from r.
+//
+// The cookies are added to the existing sessions.
+func (s *Sessions) Load(r io.Reader) error {
+	var all map[string][]jarEntry
+
+	if err := json.NewDecoder(r).Decode(&all); err != nil {
+		return fmt.Errorf("ant: load sessions - %w", err)
+	}
+
+	for name, entries := range all {
+		if err := s.Jar(name).restore(entries); err != nil {
+			return fmt.Errorf("ant: load sessions - %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SaveFile writes all sessions to file.
+func (s *Sessions) SaveFile(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("ant: save sessions - %w", err)
+	}
+
+	if err := s.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadFile reads sessions from file.
+//
+// If the file does not exist, the method is a no-op.
+func (s *Sessions) LoadFile(file string) error {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ant: load sessions - %w", err)
+	}
+	defer f.Close()
+
+	return s.Load(f)
+}
+
+// FormRequest returns a POST request that submits values to u.
+//
+// The values are URL encoded, the request can be used
+// to log in before or during a crawl, the response's cookies
+// are added to the fetcher's session.
+func FormRequest(u *URL, values url.Values) *Request {
+	return &Request{
+		Method: "POST",
+		URL:    u,
+		Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		Body:   []byte(values.Encode()),
+	}
+}
@@ -0,0 +1,263 @@
+package ant
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	t.Run("login", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var fetcher = &Fetcher{Sessions: &Sessions{}}
+
+		page, err := fetcher.FetchRequest(ctx, FormRequest(parseURL(t, srv.URL+"/login"), url.Values{
+			"user": {"ant"},
+		}))
+		assert.NoError(err)
+		assert.Equal("/home", page.URL.Path)
+		page.close()
+
+		page, err = fetcher.Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.NoError(err)
+		assert.Equal("ant", page.Text("title"))
+		page.close()
+
+		_, err = (&Fetcher{}).Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.Error(err)
+		assert.Contains(err.Error(), "403")
+	})
+
+	t.Run("login with custom client", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var client = &http.Client{}
+
+		fetcher := &Fetcher{
+			Client: ClientFunc(func(req *http.Request) (*http.Response, error) {
+				return client.Do(req)
+			}),
+			Sessions: &Sessions{},
+		}
+
+		page, err := fetcher.FetchRequest(ctx, FormRequest(parseURL(t, srv.URL+"/login?next=/"), url.Values{
+			"user": {"ant"},
+		}))
+		assert.NoError(err)
+		page.close()
+
+		page, err = fetcher.Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.NoError(err)
+		assert.Equal("ant", page.Text("title"))
+		page.close()
+	})
+
+	t.Run("select session", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var sessions = &Sessions{}
+		var fetcher = &Fetcher{Sessions: sessions}
+
+		a := WithSession(ctx, "a")
+		page, err := fetcher.FetchRequest(a, FormRequest(parseURL(t, srv.URL+"/login"), url.Values{
+			"user": {"a"},
+		}))
+		assert.NoError(err)
+		page.close()
+
+		_, err = fetcher.Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.Error(err)
+
+		page, err = fetcher.Fetch(a, parseURL(t, srv.URL+"/private"))
+		assert.NoError(err)
+		assert.Equal("a", page.Text("title"))
+		page.close()
+
+		sessions.PerHost = true
+		host := parseURL(t, srv.URL).Hostname()
+		assert.Same(sessions.Jar(host), sessions.jar(ctx, NewRequest(parseURL(t, srv.URL))))
+		assert.Same(sessions.Jar("a"), sessions.jar(a, NewRequest(parseURL(t, srv.URL))))
+
+		req := NewRequest(parseURL(t, srv.URL))
+		req.Session = "b"
+		assert.Same(sessions.Jar("b"), sessions.jar(a, req))
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var file = filepath.Join(t.TempDir(), "sessions.json")
+		var sessions = &Sessions{}
+		var fetcher = &Fetcher{Sessions: sessions}
+
+		page, err := fetcher.FetchRequest(ctx, FormRequest(parseURL(t, srv.URL+"/login"), url.Values{
+			"user": {"ant"},
+		}))
+		assert.NoError(err)
+		page.close()
+
+		u := parseURL(t, srv.URL)
+		sessions.Jar("").SetCookies(u, []*http.Cookie{
+			{Name: "expired", Value: "1", Expires: time.Now().Add(-time.Hour)},
+			{Name: "short", Value: "1", MaxAge: 1},
+		})
+
+		assert.NoError(sessions.SaveFile(file))
+
+		loaded := &Sessions{}
+		assert.NoError(loaded.LoadFile(file))
+
+		entries := loaded.Jar("").snapshot(time.Now())
+		assert.Len(entries, 2)
+
+		fetcher = &Fetcher{Sessions: loaded}
+		page, err = fetcher.Fetch(ctx, parseURL(t, srv.URL+"/private"))
+		assert.NoError(err)
+		assert.Equal("ant", page.Text("title"))
+		page.close()
+
+		assert.NoError((&Sessions{}).LoadFile(filepath.Join(t.TempDir(), "missing.json")))
+	})
+
+	t.Run("engine", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var mu sync.Mutex
+		var titles []string
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Fetcher:  &Fetcher{Sessions: &Sessions{}},
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				mu.Lock()
+				titles = append(titles, p.Text("title"))
+				mu.Unlock()
+
+				if p.URL.Path == "/" {
+					return Requests{FormRequest(p.URL.ResolveReference(&URL{Path: "/login"}), url.Values{
+						"user": {"ant"},
+					})}, nil
+				}
+
+				return RequestsOf(p.URLs()), nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+		assert.Equal([]string{"index", "home", "ant"}, titles)
+	})
+
+	t.Run("engine request session", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = login(t)
+		var mu sync.Mutex
+		var titles []string
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Fetcher:  &Fetcher{Sessions: &Sessions{}},
+			Scraper: ScraperFrom(requestScraperFunc(func(ctx context.Context, p *Page) (Requests, error) {
+				mu.Lock()
+				titles = append(titles, p.Text("title"))
+				mu.Unlock()
+
+				switch p.URL.Path {
+				case "/":
+					var reqs Requests
+					for _, user := range []string{"a", "b"} {
+						r := FormRequest(p.URL.ResolveReference(&URL{Path: "/login"}), url.Values{
+							"user": {user},
+						})
+						r.Session = user
+						r.Meta = map[string]any{"user": user}
+						reqs = append(reqs, r)
+					}
+					return reqs, nil
+
+				case "/home":
+					user := p.Meta["user"].(string)
+					r := NewRequest(p.URL.ResolveReference(&URL{Path: "/private", RawQuery: "as=" + user}))
+					r.Session = user
+					return Requests{r}, nil
+				}
+
+				return nil, nil
+			})),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL))
+
+		sort.Strings(titles)
+		assert.Equal([]string{"a", "b", "home", "home", "index"}, titles)
+	})
+
+	t.Run("engine without sessions", func(t *testing.T) {
+		var assert = require.New(t)
+
+		eng, err := NewEngine(EngineConfig{Scraper: &visitor{}})
+		assert.NoError(err)
+		assert.Nil(eng.fetcher.Sessions)
+	})
+}
+
+// Login returns a server that requires a session cookie.
+//
+// The server sets the cookie on `POST /login` and redirects
+// to `/home`, or to the `next` query parameter, `/private`
+// responds with the user's name.
+func login(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/":
+			io.WriteString(w, `<title>index</title>`)
+
+		case "/login":
+			r.ParseForm()
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: r.PostForm.Get("user"), Path: "/"})
+			if next := r.URL.Query().Get("next"); next != "" {
+				io.WriteString(w, `<title>logged in</title>`)
+				return
+			}
+			http.Redirect(w, r, "/home", 302)
+
+		case "/home":
+			if _, err := r.Cookie("sid"); err != nil {
+				w.WriteHeader(403)
+				return
+			}
+			io.WriteString(w, `<title>home</title><a href="/private">private</a>`)
+
+		case "/private":
+			c, err := r.Cookie("sid")
+			if err != nil {
+				w.WriteHeader(403)
+				return
+			}
+			io.WriteString(w, "<title>"+strings.TrimSpace(c.Value)+"</title>")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
@@ -0,0 +1,465 @@
+package ant
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yields/ant/internal/robots"
+	"golang.org/x/net/html/charset"
+)
+
+// MaxSitemapSize is the default maximum size of
+// a decompressed sitemap, as defined by the protocol.
+const maxSitemapSize = 50 << 20
+
+// MaxSitemapDepth is the maximum nesting of sitemap indexes.
+const maxSitemapDepth = 3
+
+// Seeder represents a seeder.
+//
+// A seeder returns additional requests to queue when the
+// engine starts, it is called with the engine's start URLs.
+type Seeder interface {
+	// Seed returns the requests to queue.
+	//
+	// The requests are queued like any other request, they
+	// are de-duplicated and matched before they are queued.
+	Seed(ctx context.Context, urls []*URL) (Requests, error)
+}
+
+// SitemapEntry represents a sitemap URL entry.
+type SitemapEntry struct {
+	// URL is the entry's location.
+	URL *URL
+
+	// LastMod is the time the page was last modified.
+	//
+	// It is zero if the sitemap does not specify it.
+	LastMod time.Time
+
+	// Priority is the page's priority between 0 and 1.
+	//
+	// It is zero if the sitemap does not specify it.
+	Priority float64
+
+	// ChangeFreq is how frequently the page is likely to change.
+	ChangeFreq string
+}
+
+// Sitemaps implements a seeder that reads sitemaps.
+//
+// By default the sitemaps are discovered for each host of the
+// start URLs, they are read from the `Sitemap:` lines of the
+// host's robots.txt or, if it does not list any, from `/sitemap.xml`.
+//
+// Sitemap indexes are followed and gzip compressed sitemaps are
+// decompressed, the entries are queued with their sitemap priority.
+//
+// Sitemaps that cannot be fetched or parsed are logged and skipped,
+// seeding fails only if none of the sitemaps could be read.
+//
+// A sitemaps value must not be copied after first use.
+type Sitemaps struct {
+	// URLs are the sitemap URLs to read.
+	//
+	// If empty, the sitemaps are discovered.
+	URLs []string
+
+	// Since skips entries that were modified before since.
+	//
+	// It can be set to the time of the last crawl to visit
+	// only changed pages, entries without a modification
+	// time are always included.
+	//
+	// If zero, all entries are included.
+	Since time.Time
+
+	// MaxEntries is the maximum amount of entries to read.
+	//
+	// If <= 0, all entries are read.
+	MaxEntries int
+
+	// MaxSize is the maximum size of a decompressed sitemap.
+	//
+	// If <= 0, defaults to 50MB.
+	MaxSize int64
+
+	// Client is the client to use.
+	//
+	// If nil, ant.DefaultClient is used.
+	Client *http.Client
+
+	// Logger is the logger to use.
+	//
+	// Skipped sitemaps are logged at warn level.
+	//
+	// If nil, the sitemaps are skipped silently.
+	Logger *slog.Logger
+
+	once   sync.Once
+	robots *robots.Cache
+}
+
+// Seed implementation.
+func (s *Sitemaps) Seed(ctx context.Context, urls []*URL) (Requests, error) {
+	entries, err := s.Entries(ctx, urls...)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqs = make(Requests, 0, len(entries))
+
+	for _, e := range entries {
+		r := NewRequest(e.URL)
+		r.Priority = e.Priority
+		reqs = append(reqs, r)
+	}
+
+	return reqs, nil
+}
+
+// Entries returns the sitemap entries for the given URLs.
+//
+// Sitemaps that do not exist are skipped, sitemaps that cannot
+// be fetched or parsed are logged and skipped as well, the method
+// returns the first error only if no entries were read.
+func (s *Sitemaps) Entries(ctx context.Context, urls ...*URL) ([]SitemapEntry, error) {
+	var ret []SitemapEntry
+	var seen = make(map[string]bool)
+
+	locs, failed := s.locate(ctx, urls)
+
+	for _, loc := range locs {
+		if err := s.read(ctx, loc, 0, seen, &ret); err != nil && failed == nil {
+			failed = err
+		}
+		if s.full(ret) {
+			break
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 && failed != nil {
+		return nil, failed
+	}
+
+	return ret, nil
+}
+
+// Locate returns the sitemap URLs to read.
+//
+// URLs that cannot be parsed and hosts whose sitemaps cannot be
+// discovered are skipped, the method returns the first error.
+func (s *Sitemaps) locate(ctx context.Context, urls []*URL) ([]*URL, error) {
+	var ret []*URL
+	var failed error
+
+	if len(s.URLs) > 0 {
+		for _, rawurl := range s.URLs {
+			u, err := url.Parse(rawurl)
+			if err != nil {
+				err = fmt.Errorf("ant: parse sitemap url %q - %w", rawurl, err)
+				failed = s.skipped(ctx, rawurl, err, failed)
+				continue
+			}
+			ret = append(ret, u)
+		}
+		return ret, failed
+	}
+
+	var hosts = make(map[string]bool)
+
+	for _, u := range urls {
+		var root = u.Scheme + "://" + u.Host
+
+		if hosts[root] {
+			continue
+		}
+		hosts[root] = true
+
+		rawurls, err := s.cache().Sitemaps(ctx, u)
+		if err != nil {
+			err = fmt.Errorf("ant: discover sitemaps - %w", err)
+			failed = s.skipped(ctx, root+"/robots.txt", err, failed)
+			continue
+		}
+
+		if len(rawurls) == 0 {
+			rawurls = []string{root + "/sitemap.xml"}
+		}
+
+		for _, rawurl := range rawurls {
+			if u, err := url.Parse(rawurl); err == nil {
+				ret = append(ret, u)
+			}
+		}
+	}
+
+	return ret, failed
+}
+
+// Read reads the sitemap at u and appends its entries to dst.
+//
+// Sitemap indexes are read recursively up to the maximum
+// depth, sitemaps that were already read are skipped.
+//
+// Sitemaps that fail are skipped, the method
+// returns the first error.
+func (s *Sitemaps) read(ctx context.Context, u *URL, depth int, seen map[string]bool, dst *[]SitemapEntry) error {
+	if depth > maxSitemapDepth || seen[u.String()] {
+		return nil
+	}
+	seen[u.String()] = true
+
+	doc, err := s.fetch(ctx, u)
+	if err != nil {
+		return s.skipped(ctx, u.String(), err, nil)
+	}
+
+	if doc == nil {
+		return nil
+	}
+
+	for _, e := range doc.URLs {
+		if s.full(*dst) {
+			return nil
+		}
+
+		loc, ok := resolve(u, e.Loc)
+		if !ok {
+			continue
+		}
+
+		entry := SitemapEntry{
+			URL:        loc,
+			LastMod:    parseLastMod(e.LastMod),
+			ChangeFreq: strings.TrimSpace(e.ChangeFreq),
+		}
+
+		if p, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+			entry.Priority = min(max(p, 0), 1)
+		}
+
+		if s.skip(entry.LastMod) {
+			continue
+		}
+
+		*dst = append(*dst, entry)
+	}
+
+	var failed error
+
+	for _, e := range doc.Sitemaps {
+		loc, ok := resolve(u, e.Loc)
+		if !ok || s.skip(parseLastMod(e.LastMod)) {
+			continue
+		}
+
+		if err := s.read(ctx, loc, depth+1, seen, dst); err != nil && failed == nil {
+			failed = err
+		}
+	}
+
+	return failed
+}
+
+// Fetch fetches and parses the sitemap at u.
+//
+// The method returns a nil document if
+// the sitemap does not exist.
+func (s *Sitemaps) fetch(ctx context.Context, u *URL) (*sitemapDoc, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ant: new request - %w", err)
+	}
+
+	req.Header.Set("User-Agent", UserAgent.String())
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ant: GET %q - %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == 404 || resp.StatusCode == 410:
+		return nil, nil
+	case resp.StatusCode >= 400:
+		return nil, &FetchError{URL: u, Status: resp.StatusCode}
+	}
+
+	var br = bufio.NewReader(resp.Body)
+	var r io.Reader = br
+
+	// Compressed sitemaps are usually served without
+	// a content encoding, detect them by their header.
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("ant: parse sitemap %q - %w", u, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var doc sitemapDoc
+	var dec = xml.NewDecoder(&limitReader{r: r, n: s.maxSize()})
+
+	dec.CharsetReader = charset.NewReaderLabel
+
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("ant: parse sitemap %q - %w", u, err)
+	}
+
+	return &doc, nil
+}
+
+// Skipped logs that the sitemap at rawurl was skipped with err.
+//
+// The method returns failed, or err if failed is nil.
+func (s *Sitemaps) skipped(ctx context.Context, rawurl string, err, failed error) error {
+	s.logger().WarnContext(ctx, "ant: skip sitemap",
+		"url", rawurl,
+		"error", err,
+	)
+
+	if failed != nil {
+		return failed
+	}
+
+	return err
+}
+
+// Full returns true if the maximum amount of entries were read.
+func (s *Sitemaps) full(entries []SitemapEntry) bool {
+	return s.MaxEntries > 0 && len(entries) >= s.MaxEntries
+}
+
+// Skip returns true if lastmod is before since.
+func (s *Sitemaps) skip(lastmod time.Time) bool {
+	return !s.Since.IsZero() && !lastmod.IsZero() && lastmod.Before(s.Since)
+}
+
+// MaxSize returns the maximum sitemap size.
+func (s *Sitemaps) maxSize() int64 {
+	if s.MaxSize > 0 {
+		return s.MaxSize
+	}
+	return maxSitemapSize
+}
+
+// Client returns the client to use.
+func (s *Sitemaps) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return DefaultClient
+}
+
+// Logger returns the logger to use.
+func (s *Sitemaps) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return discard
+}
+
+// Cache returns the robots.txt cache.
+func (s *Sitemaps) cache() *robots.Cache {
+	s.once.Do(func() {
+		s.robots = robots.NewCache(s.client(), 100)
+	})
+	return s.robots
+}
+
+// SitemapDoc represents a sitemap or a sitemap index.
+type sitemapDoc struct {
+	URLs []struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod"`
+		ChangeFreq string `xml:"changefreq"`
+		Priority   string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// LimitReader is a reader that fails after n bytes.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implementation.
+func (lr *limitReader) Read(p []byte) (int, error) {
+	if lr.n <= 0 {
+		return 0, ErrBodyTooLarge
+	}
+
+	if int64(len(p)) > lr.n {
+		p = p[:lr.n]
+	}
+
+	n, err := lr.r.Read(p)
+	lr.n -= int64(n)
+	return n, err
+}
+
+// Resolve resolves the sitemap location loc against base.
+//
+// The method returns false if loc is not an HTTP URL.
+func resolve(base *URL, loc string) (*URL, bool) {
+	u, err := url.Parse(strings.TrimSpace(loc))
+	if err != nil {
+		return nil, false
+	}
+
+	u = base.ResolveReference(u)
+
+	switch u.Scheme {
+	case "http", "https":
+		return u, true
+	default:
+		return nil, false
+	}
+}
+
+// ParseLastMod parses a W3C datetime.
+//
+// The method returns a zero time if v is invalid.
+func parseLastMod(v string) time.Time {
+	var layouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02",
+		"2006-01",
+		"2006",
+	}
+
+	v = strings.TrimSpace(v)
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,297 @@
+package ant
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemaps(t *testing.T) {
+	t.Run("robots", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, true)
+		var s = &Sitemaps{}
+
+		entries, err := s.Entries(ctx, parseURL(t, srv.URL+"/a"), parseURL(t, srv.URL+"/b"))
+		assert.NoError(err)
+		assert.Len(entries, 4)
+
+		assert.Equal(srv.URL+"/new", entries[0].URL.String())
+		assert.Equal(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC), entries[0].LastMod.UTC())
+		assert.Equal(0.8, entries[0].Priority)
+		assert.Equal("daily", entries[0].ChangeFreq)
+
+		assert.Equal(srv.URL+"/old", entries[1].URL.String())
+		assert.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), entries[1].LastMod)
+
+		assert.Equal(srv.URL+"/news", entries[2].URL.String())
+		assert.True(entries[2].LastMod.IsZero())
+		assert.Zero(entries[2].Priority)
+
+		assert.Equal(srv.URL+"/stale", entries[3].URL.String())
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, false)
+		var s = &Sitemaps{}
+
+		entries, err := s.Entries(ctx, parseURL(t, srv.URL))
+		assert.NoError(err)
+		assert.Len(entries, 2)
+	})
+
+	t.Run("urls", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, false)
+		var s = &Sitemaps{URLs: []string{srv.URL + "/news.xml.gz"}}
+
+		entries, err := s.Entries(ctx)
+		assert.NoError(err)
+		assert.Len(entries, 1)
+		assert.Equal(srv.URL+"/news", entries[0].URL.String())
+	})
+
+	t.Run("since", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, true)
+		var s = &Sitemaps{Since: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+		entries, err := s.Entries(ctx, parseURL(t, srv.URL))
+		assert.NoError(err)
+		assert.Len(entries, 2)
+		assert.Equal(srv.URL+"/new", entries[0].URL.String())
+		assert.Equal(srv.URL+"/news", entries[1].URL.String())
+	})
+
+	t.Run("max entries", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, true)
+		var s = &Sitemaps{MaxEntries: 1}
+
+		entries, err := s.Entries(ctx, parseURL(t, srv.URL))
+		assert.NoError(err)
+		assert.Len(entries, 1)
+	})
+
+	t.Run("max size", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, false)
+		var s = &Sitemaps{MaxSize: 10}
+
+		_, err := s.Entries(ctx, parseURL(t, srv.URL))
+		assert.Error(err)
+		assert.ErrorIs(err, ErrBodyTooLarge)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var s = &Sitemaps{}
+		var u = serve(t, func(w http.ResponseWriter) {
+			w.WriteHeader(404)
+		})
+
+		entries, err := s.Entries(ctx, u)
+		assert.NoError(err)
+		assert.Empty(entries)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var u = serve(t, func(w http.ResponseWriter) {
+			w.WriteHeader(500)
+		})
+		var s = &Sitemaps{URLs: []string{u.String()}}
+		var ferr *FetchError
+
+		_, err := s.Entries(ctx)
+		assert.Error(err)
+		assert.ErrorAs(err, &ferr)
+		assert.Equal(500, ferr.Status)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, false)
+		var buf strings.Builder
+		var s = &Sitemaps{
+			URLs: []string{
+				srv.URL + "/error.xml",
+				srv.URL + "/broken.xml",
+				srv.URL + "/news.xml.gz",
+			},
+			Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+		}
+
+		entries, err := s.Entries(ctx)
+		assert.NoError(err)
+		assert.Len(entries, 1)
+		assert.Equal(srv.URL+"/news", entries[0].URL.String())
+		assert.Equal(2, strings.Count(buf.String(), "ant: skip sitemap"))
+	})
+
+	t.Run("engine", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, true)
+		var mu sync.Mutex
+		var paths []string
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Seeder:   &Sitemaps{},
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				mu.Lock()
+				paths = append(paths, p.URL.Path)
+				mu.Unlock()
+				return nil, nil
+			}),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL+"/new"))
+
+		sort.Strings(paths)
+		assert.Equal([]string{"/new", "/news", "/old", "/stale"}, paths)
+	})
+
+	t.Run("engine skip", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var srv = sitemaps(t, false)
+		var mu sync.Mutex
+		var paths []string
+
+		eng, err := NewEngine(EngineConfig{
+			Impolite: true,
+			Seeder: &Sitemaps{URLs: []string{
+				srv.URL + "/error.xml",
+				srv.URL + "/news.xml.gz",
+			}},
+			Scraper: scraperFunc(func(ctx context.Context, p *Page) (URLs, error) {
+				mu.Lock()
+				paths = append(paths, p.URL.Path)
+				mu.Unlock()
+				return nil, nil
+			}),
+		})
+		assert.NoError(err)
+		assert.NoError(eng.Run(ctx, srv.URL+"/new"))
+
+		sort.Strings(paths)
+		assert.Equal([]string{"/new", "/news"}, paths)
+	})
+}
+
+func TestParseLastMod(t *testing.T) {
+	var cases = []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-05-01T10:30:15.5Z", time.Date(2024, 5, 1, 10, 30, 15, 5e8, time.UTC)},
+		{"2024-05-01T10:30:15Z", time.Date(2024, 5, 1, 10, 30, 15, 0, time.UTC)},
+		{"2024-05-01T10:30Z", time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)},
+		{" 2024-05-01 ", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-05", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.Time{}},
+		{"", time.Time{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			require.New(t).True(c.want.Equal(parseLastMod(c.value)))
+		})
+	}
+}
+
+// Sitemaps returns a server that serves sitemaps.
+//
+// The server serves a sitemap index at `/index.xml` that
+// points to `/sitemap.xml`, the gzipped `/news.xml.gz` and the
+// old `/stale.xml`, when robots is true robots.txt lists the index.
+//
+// The sitemaps at `/error.xml` and `/broken.xml` are invalid.
+func sitemaps(t testing.TB, robots bool) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var base = srv.URL
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			if !robots {
+				w.WriteHeader(404)
+				return
+			}
+			io.WriteString(w, "User-Agent: *\nSitemap: "+base+"/index.xml\n")
+
+		case "/index.xml":
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+				<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<sitemap><loc>`+base+`/sitemap.xml</loc></sitemap>
+					<sitemap><loc>/news.xml.gz</loc><lastmod>2024-06-01</lastmod></sitemap>
+					<sitemap><loc>`+base+`/index.xml</loc></sitemap>
+					<sitemap><loc>`+base+`/stale.xml</loc><lastmod>2019-01-01</lastmod></sitemap>
+				</sitemapindex>`)
+
+		case "/sitemap.xml":
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+				<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<url>
+						<loc> `+base+`/new </loc>
+						<lastmod>2024-05-01T10:30:00+00:00</lastmod>
+						<changefreq>daily</changefreq>
+						<priority>0.8</priority>
+					</url>
+					<url><loc>`+base+`/old</loc><lastmod>2020-01-01</lastmod></url>
+					<url><loc>mailto:ant@example.com</loc></url>
+				</urlset>`)
+
+		case "/news.xml.gz":
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			io.WriteString(gz, `<urlset><url><loc>`+base+`/news</loc></url></urlset>`)
+			gz.Close()
+			w.Header().Set("Content-Type", "application/x-gzip")
+			w.Write(buf.Bytes())
+
+		case "/stale.xml":
+			io.WriteString(w, `<urlset><url><loc>`+base+`/stale</loc></url></urlset>`)
+
+		case "/error.xml":
+			w.WriteHeader(500)
+
+		case "/broken.xml":
+			io.WriteString(w, `<urlset><url><loc>`+base+`/broken`)
+
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<title>"+strings.TrimPrefix(r.URL.Path, "/")+"</title>")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
@@ -0,0 +1,91 @@
+package ant
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Throttle implements a per-host gate.
+//
+// When a host responds with a 429 or 503 status the fetcher
+// closes the host's gate for the duration of the `Retry-After`
+// header, or its retry wait if the header is missing, all
+// requests to the host wait until the gate opens again.
+//
+// A throttle can be shared by multiple fetchers,
+// it must not be copied after first use.
+type Throttle struct {
+	mu    sync.Mutex
+	hosts map[string]time.Time
+}
+
+// Delay closes the gate of host for d.
+//
+// If the gate is already closed for longer, the method is a no-op.
+func (t *Throttle) delay(host string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.hosts == nil {
+		t.hosts = make(map[string]time.Time)
+	}
+
+	if until := time.Now().Add(d); until.After(t.hosts[host]) {
+		t.hosts[host] = until
+	}
+}
+
+// Wait blocks until the gate of host is open
+// or the context is canceled.
+func (t *Throttle) wait(ctx context.Context, host string) error {
+	if t == nil {
+		return nil
+	}
+
+	for {
+		t.mu.Lock()
+		until, ok := t.hosts[host]
+		if ok && !time.Now().Before(until) {
+			delete(t.hosts, host)
+			ok = false
+		}
+		t.mu.Unlock()
+
+		if !ok {
+			return nil
+		}
+
+		if err := sleep(ctx, time.Until(until)); err != nil {
+			return err
+		}
+	}
+}
+
+// RetryAfter returns the delay of the Retry-After header.
+//
+// The header is either an amount of seconds or an HTTP date,
+// the method returns false if the header is missing or invalid.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	var v = h.Get("Retry-After")
+
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+
+	return 0, false
+}
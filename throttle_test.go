@@ -0,0 +1,116 @@
+package ant
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	t.Run("retry after", func(t *testing.T) {
+		var assert = require.New(t)
+		var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		cases := []struct {
+			value string
+			delay time.Duration
+			ok    bool
+		}{
+			{"", 0, false},
+			{"120", 2 * time.Minute, true},
+			{"-1", 0, true},
+			{"Wed, 01 Jan 2020 00:00:30 GMT", 30 * time.Second, true},
+			{"Tue, 31 Dec 2019 23:00:00 GMT", 0, true},
+			{"soon", 0, false},
+		}
+
+		for _, c := range cases {
+			delay, ok := retryAfter(http.Header{"Retry-After": {c.value}}, now)
+			assert.Equal(c.ok, ok, c.value)
+			assert.Equal(c.delay, delay, c.value)
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var throttle = &Throttle{}
+
+		throttle.delay("a", 50*time.Millisecond)
+		throttle.delay("a", 10*time.Millisecond)
+
+		start := time.Now()
+		assert.NoError(throttle.wait(ctx, "b"))
+		assert.True(time.Since(start) < 50*time.Millisecond)
+
+		assert.NoError(throttle.wait(ctx, "a"))
+		assert.True(time.Since(start) >= 50*time.Millisecond)
+		assert.Empty(throttle.hosts)
+
+		throttle.delay("a", time.Minute)
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(throttle.wait(ctx, "a"), context.DeadlineExceeded)
+	})
+
+	t.Run("fetch honours retry after", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var reqs uint64
+		var delays []time.Duration
+		var url = serve(t, func(w http.ResponseWriter) {
+			if atomic.AddUint64(&reqs, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(429)
+			}
+		})
+
+		fetcher := &Fetcher{Throttle: &Throttle{}}
+		ctx = withHooks(ctx, &Hooks{
+			OnThrottle: func(_ context.Context, _ *URL, d time.Duration) {
+				delays = append(delays, d)
+			},
+		})
+
+		start := time.Now()
+		p, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		assert.NoError(p.close())
+		assert.True(time.Since(start) >= time.Second)
+		assert.Equal([]time.Duration{time.Second}, delays)
+		assert.Equal(uint64(2), reqs)
+	})
+
+	t.Run("fetch max retry after", func(t *testing.T) {
+		var ctx = context.Background()
+		var assert = require.New(t)
+		var reqs uint64
+		var url = serve(t, func(w http.ResponseWriter) {
+			if atomic.AddUint64(&reqs, 1) == 1 {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(503)
+			}
+		})
+
+		fetcher := &Fetcher{
+			Throttle:      &Throttle{},
+			MaxRetryAfter: 50 * time.Millisecond,
+		}
+
+		_, err := fetcher.Fetch(ctx, url)
+		assert.Error(err)
+		assert.Contains(err.Error(), "503")
+		assert.Equal(uint64(1), reqs)
+
+		start := time.Now()
+		p, err := fetcher.Fetch(ctx, url)
+		assert.NoError(err)
+		assert.NoError(p.close())
+		assert.True(time.Since(start) >= 40*time.Millisecond)
+		assert.Equal(uint64(2), reqs)
+	})
+}